@@ -45,12 +45,54 @@ type GRPCConfig struct {
 	Host         string        `yaml:"host" env:"HOST" envDefault:"0.0.0.0"`
 	ReadTimeout  time.Duration `yaml:"read_timeout" env:"READ_TIMEOUT" envDefault:"30s"`
 	WriteTimeout time.Duration `yaml:"write_timeout" env:"WRITE_TIMEOUT" envDefault:"30s"`
+	// TrustedProxies lists the proxies (IPs or CIDRs) whose x-forwarded-for
+	// header is trusted to carry the real client address.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" envSeparator:","`
+	// Reflection registers the server reflection service, for tools like
+	// grpcurl during development.
+	Reflection bool `yaml:"reflection" env:"REFLECTION" envDefault:"false"`
+	// ShutdownDrain is how long the server keeps serving after reporting
+	// NOT_SERVING, so load balancers stop routing to it before it stops.
+	// It counts against app.shutdown_timeout.
+	ShutdownDrain time.Duration `yaml:"shutdown_drain" env:"SHUTDOWN_DRAIN" envDefault:"5s"`
 }
 
 func (c *GRPCConfig) Address() string {
 	return fmt.Sprintf("%s:%s", c.Host, c.Port)
 }
 
+// HealthConfig sets how often the dependencies reported by the health
+// service are checked.
+type HealthConfig struct {
+	CheckInterval time.Duration `yaml:"check_interval" env:"CHECK_INTERVAL" envDefault:"10s"`
+	CheckTimeout  time.Duration `yaml:"check_timeout" env:"CHECK_TIMEOUT" envDefault:"2s"`
+}
+
+// MetricsConfig sets up the listener serving Prometheus metrics, kept
+// apart from the public HTTP port.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"ENABLED" envDefault:"true"`
+	Port    string `yaml:"port" env:"PORT" envDefault:"9100"`
+	Host    string `yaml:"host" env:"HOST" envDefault:"0.0.0.0"`
+	Path    string `yaml:"path" env:"PATH" envDefault:"/metrics"`
+}
+
+func (c *MetricsConfig) Address() string {
+	return fmt.Sprintf("%s:%s", c.Host, c.Port)
+}
+
+// TracingConfig sets up OpenTelemetry tracing. Exporter is "otlp", sending
+// over gRPC to Endpoint, or "stdout" and "file" writing JSON for local runs.
+// An empty Endpoint leaves it to the OTEL_EXPORTER_OTLP_* variables.
+type TracingConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"ENABLED" envDefault:"false"`
+	Exporter    string  `yaml:"exporter" env:"EXPORTER" envDefault:"otlp"`
+	Endpoint    string  `yaml:"endpoint" env:"ENDPOINT" envDefault:"localhost:4317"`
+	Insecure    bool    `yaml:"insecure" env:"INSECURE" envDefault:"true"`
+	FilePath    string  `yaml:"file_path" env:"FILE_PATH" envDefault:"traces.json"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"SAMPLE_RATIO" envDefault:"1"`
+}
+
 type RedisConfig struct {
 	Host     string        `yaml:"-" env:"HOST" envDefault:"localhost"`
 	Port     string        `yaml:"-" env:"PORT" envDefault:"6379"`
@@ -64,10 +106,153 @@ func (c *RedisConfig) Address() string {
 	return fmt.Sprintf("%s:%s", c.Host, c.Port)
 }
 
+// JWTKeyConfig describes one entry of the signing key set. HMAC keys read the
+// secret from SecretPath, asymmetric keys need at least one of the PEM paths;
+// keys with only a public key are accepted for verification but cannot sign.
+type JWTKeyConfig struct {
+	ID             string `yaml:"kid"`
+	Algorithm      string `yaml:"algorithm"`
+	SecretPath     string `yaml:"secret_path"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+	PublicKeyPath  string `yaml:"public_key_path"`
+	Retired        bool   `yaml:"retired"`
+}
+
 type JWTConfig struct {
-	Secret          string        `yaml:"-" env:"SECRET_KEY"`
-	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
-	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" envDefault:"168h"`
+	Secret            string         `yaml:"-" env:"SECRET_KEY"`
+	Algorithm         string         `yaml:"algorithm" env:"ALGORITHM" envDefault:"HS256"`
+	PrivateKeyPath    string         `yaml:"-" env:"PRIVATE_KEY_PATH"`
+	PublicKeyPath     string         `yaml:"-" env:"PUBLIC_KEY_PATH"`
+	KeyID             string         `yaml:"key_id" env:"KEY_ID"`
+	ActiveKeyID       string         `yaml:"active_key_id" env:"ACTIVE_KEY_ID"`
+	Keys              []JWTKeyConfig `yaml:"keys"`
+	Issuer            string         `yaml:"issuer" env:"ISSUER" envDefault:"auth-service"`
+	Audience          []string       `yaml:"audience" env:"AUDIENCE" envSeparator:","`
+	AcceptedAudiences []string       `yaml:"accepted_audiences" env:"ACCEPTED_AUDIENCES" envSeparator:","`
+	AccessTokenTTL    time.Duration  `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL" envDefault:"15m"`
+	RefreshTokenTTL   time.Duration  `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" envDefault:"168h"`
+	// RefreshReuseGrace is how long the refresh token replaced by the last
+	// rotation is still accepted, to cover concurrent refreshes of one client.
+	RefreshReuseGrace time.Duration `yaml:"refresh_reuse_grace" env:"REFRESH_REUSE_GRACE" envDefault:"10s"`
+}
+
+// SessionCookieConfig sets up the browser session mode of the HTTP gateway:
+// clients opting in get their tokens as HttpOnly cookies instead of in the
+// response body, so scripts can't read them.
+type SessionCookieConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED" envDefault:"false"`
+	// AccessToken also moves the access token into a cookie, by default it
+	// stays in the body and only the refresh token is kept in a cookie.
+	AccessToken bool   `yaml:"access_token" env:"ACCESS_TOKEN" envDefault:"false"`
+	Domain      string `yaml:"domain" env:"DOMAIN"`
+	Path        string `yaml:"path" env:"PATH" envDefault:"/"`
+	Secure      bool   `yaml:"secure" env:"SECURE" envDefault:"true"`
+	// SameSite is strict, lax or none, none requires Secure.
+	SameSite    string `yaml:"same_site" env:"SAME_SITE" envDefault:"strict"`
+	RefreshName string `yaml:"refresh_name" env:"REFRESH_NAME" envDefault:"refresh_token"`
+	AccessName  string `yaml:"access_name" env:"ACCESS_NAME" envDefault:"access_token"`
+	CSRFName    string `yaml:"csrf_name" env:"CSRF_NAME" envDefault:"csrf_token"`
+}
+
+// SecurityConfig holds the abuse protection settings.
+type SecurityConfig struct {
+	// PasswordCheckMaxAttempts failed ValidatePassword calls per user are
+	// allowed within PasswordCheckWindow, further calls are refused until the
+	// window expires.
+	PasswordCheckMaxAttempts int           `yaml:"password_check_max_attempts" env:"PASSWORD_CHECK_MAX_ATTEMPTS" envDefault:"5"`
+	PasswordCheckWindow      time.Duration `yaml:"password_check_window" env:"PASSWORD_CHECK_WINDOW" envDefault:"15m"`
+	EmailChangeTokenTTL      time.Duration `yaml:"email_change_token_ttl" env:"EMAIL_CHANGE_TOKEN_TTL" envDefault:"24h"`
+	EmailVerificationTTL     time.Duration `yaml:"email_verification_ttl" env:"EMAIL_VERIFICATION_TTL" envDefault:"48h"`
+	PasswordResetTokenTTL    time.Duration `yaml:"password_reset_token_ttl" env:"PASSWORD_RESET_TOKEN_TTL" envDefault:"1h"`
+	// RequireVerifiedEmail makes Login refuse accounts whose email address
+	// has not been verified yet.
+	RequireVerifiedEmail bool `yaml:"require_verified_email" env:"REQUIRE_VERIFIED_EMAIL" envDefault:"false"`
+	// SilentDuplicateRegistration answers Register the same for new and
+	// registered addresses: no session is returned and the owner of an
+	// address already registered is notified by mail.
+	SilentDuplicateRegistration bool `yaml:"silent_duplicate_registration" env:"SILENT_DUPLICATE_REGISTRATION" envDefault:"false"`
+	// PasswordlessSignUp lets passwordless logins create accounts, without a
+	// password, for addresses that are not registered yet.
+	PasswordlessSignUp  bool          `yaml:"passwordless_sign_up" env:"PASSWORDLESS_SIGN_UP" envDefault:"true"`
+	PasswordlessLinkTTL time.Duration `yaml:"passwordless_link_ttl" env:"PASSWORDLESS_LINK_TTL" envDefault:"15m"`
+	PasswordlessCodeTTL time.Duration `yaml:"passwordless_code_ttl" env:"PASSWORDLESS_CODE_TTL" envDefault:"10m"`
+	// PasswordlessMaxAttempts wrong codes are accepted per address within
+	// PasswordlessCodeTTL.
+	PasswordlessMaxAttempts int `yaml:"passwordless_max_attempts" env:"PASSWORDLESS_MAX_ATTEMPTS" envDefault:"5"`
+	// Failed logins are counted per account and per IP within
+	// LoginFailureWindow. From LoginDelayAfter failures on, every further
+	// failure blocks logins for LoginDelayBase, doubled per failure up to
+	// LoginDelayMax. LoginLockoutAfter failures lock the account for
+	// LoginLockoutDuration. IPs are only delayed, from LoginIPDelayAfter
+	// failures on.
+	LoginFailureWindow   time.Duration `yaml:"login_failure_window" env:"LOGIN_FAILURE_WINDOW" envDefault:"15m"`
+	LoginDelayAfter      int           `yaml:"login_delay_after" env:"LOGIN_DELAY_AFTER" envDefault:"3"`
+	LoginDelayBase       time.Duration `yaml:"login_delay_base" env:"LOGIN_DELAY_BASE" envDefault:"1s"`
+	LoginDelayMax        time.Duration `yaml:"login_delay_max" env:"LOGIN_DELAY_MAX" envDefault:"1m"`
+	LoginLockoutAfter    int           `yaml:"login_lockout_after" env:"LOGIN_LOCKOUT_AFTER" envDefault:"10"`
+	LoginLockoutDuration time.Duration `yaml:"login_lockout_duration" env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+	LoginIPDelayAfter    int           `yaml:"login_ip_delay_after" env:"LOGIN_IP_DELAY_AFTER" envDefault:"100"`
+	// BootstrapAdmins are the email addresses of accounts granted the admin
+	// role on startup, so the admin-only calls can be reached on a fresh
+	// deployment. Accounts that are not registered yet are granted it on the
+	// next start.
+	BootstrapAdmins []string `yaml:"bootstrap_admins" env:"BOOTSTRAP_ADMINS" envSeparator:","`
+}
+
+// PasswordHashConfig sets the Argon2id parameters of new password hashes,
+// Memory is in KiB. Hashes made with weaker parameters are replaced at the
+// next successful login.
+type PasswordHashConfig struct {
+	Memory      uint32 `yaml:"memory" env:"MEMORY" envDefault:"65536"`
+	Iterations  uint32 `yaml:"iterations" env:"ITERATIONS" envDefault:"3"`
+	Parallelism uint8  `yaml:"parallelism" env:"PARALLELISM" envDefault:"4"`
+	SaltLength  uint32 `yaml:"salt_length" env:"SALT_LENGTH" envDefault:"16"`
+	KeyLength   uint32 `yaml:"key_length" env:"KEY_LENGTH" envDefault:"32"`
+}
+
+// MFAConfig configures TOTP two-factor authentication. EncryptionKey is the
+// base64 encoded 32 byte key TOTP secrets are encrypted with, enrolment is
+// refused while it is unset.
+type MFAConfig struct {
+	EncryptionKey string        `yaml:"-" env:"ENCRYPTION_KEY"`
+	Issuer        string        `yaml:"issuer" env:"ISSUER" envDefault:"auth-service"`
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl" env:"CHALLENGE_TTL" envDefault:"5m"`
+	// MaxAttempts wrong codes are accepted per challenge window.
+	MaxAttempts int `yaml:"max_attempts" env:"MAX_ATTEMPTS" envDefault:"5"`
+	// Skew is the number of 30 second steps a code may be off.
+	Skew          int `yaml:"skew" env:"SKEW" envDefault:"1"`
+	RecoveryCodes int `yaml:"recovery_codes" env:"RECOVERY_CODES" envDefault:"10"`
+}
+
+// WebAuthnConfig configures passkeys. RPID is the domain the passkeys are
+// bound to, RPOrigins the origins of the pages running the ceremonies.
+type WebAuthnConfig struct {
+	RPID          string   `yaml:"rp_id" env:"RP_ID" envDefault:"localhost"`
+	RPDisplayName string   `yaml:"rp_display_name" env:"RP_DISPLAY_NAME" envDefault:"auth-service"`
+	RPOrigins     []string `yaml:"rp_origins" env:"RP_ORIGINS" envSeparator:"," envDefault:"http://localhost:8080"`
+	// ChallengeTTL is how long a begun ceremony can be finished.
+	ChallengeTTL time.Duration `yaml:"challenge_ttl" env:"CHALLENGE_TTL" envDefault:"5m"`
+	// UserVerification is required, preferred or discouraged.
+	UserVerification string `yaml:"user_verification" env:"USER_VERIFICATION" envDefault:"preferred"`
+}
+
+// MailConfig configures outgoing mail. Driver is one of smtp, file or log.
+// The URLs point to the pages that receive the tokens, the token is appended
+// as the "token" query parameter. Without a URL the bare token is sent.
+type MailConfig struct {
+	Driver       string `yaml:"driver" env:"DRIVER" envDefault:"log"`
+	From         string `yaml:"from" env:"FROM" envDefault:"no-reply@auth-service.local"`
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername string `yaml:"-" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"-" env:"SMTP_PASSWORD"`
+	// FilePath is where the file driver appends messages.
+	FilePath string `yaml:"file_path" env:"FILE_PATH" envDefault:"mail.log"`
+
+	EmailVerificationURL string `yaml:"email_verification_url" env:"EMAIL_VERIFICATION_URL"`
+	EmailChangeURL       string `yaml:"email_change_url" env:"EMAIL_CHANGE_URL"`
+	PasswordResetURL     string `yaml:"password_reset_url" env:"PASSWORD_RESET_URL"`
+	PasswordlessLoginURL string `yaml:"passwordless_login_url" env:"PASSWORDLESS_LOGIN_URL"`
 }
 
 type AppConfig struct {
@@ -82,11 +267,52 @@ type AppConfig struct {
 	DBType    string `yaml:"db_type" env:"DB_TYPE" envDefault:"postgres"`
 }
 
+// RateLimitPolicy allows Requests calls per Window and caller. Key names
+// what identifies the caller: "ip", "user" or "api_key". Calls without a
+// user or a known API key are limited by IP.
+type RateLimitPolicy struct {
+	Requests int           `yaml:"requests"`
+	Window   time.Duration `yaml:"window"`
+	Key      string        `yaml:"key"`
+}
+
+// RateLimitConfig limits calls per gRPC full method, methods without a
+// policy of their own get Default. A policy without Requests is unlimited.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" env:"ENABLED" envDefault:"true"`
+	// APIKeyHeader is the metadata key carrying the API key of a caller.
+	APIKeyHeader string `yaml:"api_key_header" env:"API_KEY_HEADER" envDefault:"x-api-key"`
+	// APIKeys are the hex SHA-256 digests of the API keys counted per key.
+	// Any other key is counted per IP, so made up keys don't get a limit of
+	// their own.
+	APIKeys []string                   `yaml:"api_keys" env:"API_KEYS" envSeparator:","`
+	Default RateLimitPolicy            `yaml:"default"`
+	Methods map[string]RateLimitPolicy `yaml:"methods"`
+}
+
+// Policy returns the policy of the gRPC full method.
+func (c *RateLimitConfig) Policy(method string) RateLimitPolicy {
+	if p, ok := c.Methods[method]; ok {
+		return p
+	}
+	return c.Default
+}
+
 type Config struct {
-	App       *AppConfig      `yaml:"app" envPrefix:"APP_"`
-	Postgres  *PostgresConfig `yaml:"postgres" envPrefix:"POSTGRES_"`
-	HTTP      *HTTPConfig     `yaml:"http" envPrefix:"HTTP_"`
-	Redis     *RedisConfig    `yaml:"redis" envPrefix:"REDIS_"`
-	GRPC      *GRPCConfig     `yaml:"grpc" envPrefix:"GRPC_"`
-	JWTConfig *JWTConfig      `yaml:"jwt" envPrefix:"JWT_"`
+	App       *AppConfig           `yaml:"app" envPrefix:"APP_"`
+	Postgres  *PostgresConfig      `yaml:"postgres" envPrefix:"POSTGRES_"`
+	HTTP      *HTTPConfig          `yaml:"http" envPrefix:"HTTP_"`
+	Cookies   *SessionCookieConfig `yaml:"session_cookies" envPrefix:"SESSION_COOKIES_"`
+	Redis     *RedisConfig         `yaml:"redis" envPrefix:"REDIS_"`
+	GRPC      *GRPCConfig          `yaml:"grpc" envPrefix:"GRPC_"`
+	Health    *HealthConfig        `yaml:"health" envPrefix:"HEALTH_"`
+	Metrics   *MetricsConfig       `yaml:"metrics" envPrefix:"METRICS_"`
+	Tracing   *TracingConfig       `yaml:"tracing" envPrefix:"TRACING_"`
+	JWTConfig *JWTConfig           `yaml:"jwt" envPrefix:"JWT_"`
+	Security  *SecurityConfig      `yaml:"security" envPrefix:"SECURITY_"`
+	Password  *PasswordHashConfig  `yaml:"password_hash" envPrefix:"PASSWORD_HASH_"`
+	Mail      *MailConfig          `yaml:"mail" envPrefix:"MAIL_"`
+	MFA       *MFAConfig           `yaml:"mfa" envPrefix:"MFA_"`
+	WebAuthn  *WebAuthnConfig      `yaml:"webauthn" envPrefix:"WEBAUTHN_"`
+	RateLimit *RateLimitConfig     `yaml:"rate_limit" envPrefix:"RATE_LIMIT_"`
 }
@@ -5,11 +5,20 @@ import (
 	"fmt"
 	"github.com/Roflan4eg/auth-serivce/config"
 	"github.com/Roflan4eg/auth-serivce/internal/app/grpc"
+	"github.com/Roflan4eg/auth-serivce/internal/app/http"
 	"github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/handlers"
+	"github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/interceptors"
+	httphandlers "github.com/Roflan4eg/auth-serivce/internal/interfaces/http/handlers"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/metrics"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/ratelimit"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/tracing"
 	"github.com/Roflan4eg/auth-serivce/internal/repository"
 	"github.com/Roflan4eg/auth-serivce/internal/services"
 	"github.com/Roflan4eg/auth-serivce/internal/storage"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
 	"sync"
 )
 
@@ -20,14 +29,15 @@ type Server interface {
 }
 
 type App struct {
-	cfg        *config.Config
-	logger     *logger.Logger
-	storage    *storage.Container
-	repository *repository.Container
-	services   *services.Container
-	handlers   *handlers.Container
-	servers    []Server
-	closer     *Closer
+	cfg          *config.Config
+	logger       *logger.Logger
+	storage      *storage.Container
+	repository   *repository.Container
+	services     *services.Container
+	handlers     *handlers.Container
+	httpHandlers *httphandlers.Container
+	servers      []Server
+	closer       *Closer
 }
 
 func New(cfg *config.Config, logger *logger.Logger) *App {
@@ -39,7 +49,12 @@ func New(cfg *config.Config, logger *logger.Logger) *App {
 }
 
 func (a *App) Setup() error {
-	a.closer.Add(a.stopServers)
+	shutdownTracing, err := tracing.Setup(context.Background(), a.cfg.Tracing, a.cfg.App)
+	if err != nil {
+		return fmt.Errorf("tracing setup: %w", err)
+	}
+	a.closer.Add(shutdownTracing)
+
 	stor, err := storage.NewContainer(a.cfg, a.logger)
 	if err != nil {
 		return fmt.Errorf("storage setup: %w", err)
@@ -49,11 +64,17 @@ func (a *App) Setup() error {
 
 	a.repository = repository.NewContainer(a.storage, a.cfg, a.logger)
 	a.services = services.NewContainer(a.repository, a.cfg, a.logger)
+	err = services.BootstrapAdmins(context.Background(), a.services.UserService, a.services.RoleService, a.cfg.Security, a.logger)
+	if err != nil {
+		return fmt.Errorf("admin bootstrap: %w", err)
+	}
 	a.handlers = handlers.NewContainer(a.services, a.cfg, a.logger)
+	a.httpHandlers = httphandlers.NewContainer(a.services, a.cfg, a.logger)
 
 	if err = a.setupServers(); err != nil {
 		return fmt.Errorf("server setup: %w", err)
 	}
+	a.closer.Add(a.stopServers)
 
 	return nil
 }
@@ -87,16 +108,71 @@ func (a *App) Start() error {
 }
 
 func (a *App) setupServers() error {
+	// limits are shared through Redis, local ones take over while it fails
+	var limiter ratelimit.Limiter = ratelimit.NewMemory()
+	if a.repository.RateLimitRepo != nil {
+		limiter = ratelimit.NewFallback(a.repository.RateLimitRepo, limiter, a.logger)
+	}
+	unary := grpc.UnaryInterceptors(
+		a.logger,
+		a.services.AuthService,
+		interceptors.DefaultPolicy(a.services.UserService, a.services.AuthService),
+		limiter,
+		a.cfg.RateLimit,
+		a.handlers.Clients,
+	)
 	grpcServer := grpc.NewServer(
 		a.handlers,
+		unary,
+		grpc.NewHealth(a.storage.HealthChecks(), a.cfg.Health, a.logger),
+		a.cfg.GRPC.Reflection,
+		a.cfg.GRPC.ShutdownDrain,
 		a.logger,
 		a.cfg.GRPC.Port,
 	)
 	a.servers = append(a.servers, grpcServer)
 
+	gateway := httphandlers.NewGatewayHandler(
+		a.handlers.AuthService,
+		a.handlers.UserService,
+		interceptors.Chain(unary...),
+		a.httpHandlers.Cookies,
+		a.cfg.HTTP.WriteTimeout,
+		a.logger,
+	)
+	httpServer := http.NewServer(
+		a.httpHandlers,
+		gateway,
+		a.logger,
+		a.cfg.HTTP,
+	)
+	a.servers = append(a.servers, httpServer)
+
+	if a.cfg.Metrics.Enabled {
+		a.registerMetrics()
+		metricsServer := http.NewMetricsServer(prometheus.DefaultGatherer, a.logger, a.cfg.Metrics)
+		a.servers = append(a.servers, metricsServer)
+	}
+
 	return nil
 }
 
+// registerMetrics adds the metrics gathered from the storages to the ones
+// recorded along the way.
+func (a *App) registerMetrics() {
+	var collectors []prometheus.Collector
+	if db, ok := a.storage.SQL().(*pgxpool.Pool); ok {
+		collectors = append(collectors, metrics.NewPgxPoolCollector(db))
+	}
+	if cache, ok := a.storage.Cache().(*redis.Client); ok {
+		collectors = append(collectors, metrics.NewRedisPoolCollector(cache))
+	}
+	if a.repository.SessionRepo != nil {
+		collectors = append(collectors, metrics.NewActiveSessionsGauge(a.repository.SessionRepo))
+	}
+	prometheus.MustRegister(collectors...)
+}
+
 func (a *App) stopServers(ctx context.Context) error {
 	wg := sync.WaitGroup{}
 	wg.Add(len(a.servers))
@@ -7,12 +7,16 @@ import (
 	"github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/handlers"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
 	"net"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 type Server struct {
 	gRPCServer *grpc.Server
+	health     *Health
+	drain      time.Duration
 	port       string
 	log        *logger.Logger
 	name       string
@@ -20,17 +24,27 @@ type Server struct {
 
 func NewServer(
 	handlers *handlers.Container,
+	unary []grpc.UnaryServerInterceptor,
+	health *Health,
+	reflect bool,
+	drain time.Duration,
 	logger *logger.Logger,
 	port string,
 ) *Server {
-	opts := WithInterceptors(logger)
+	opts := WithInterceptors(unary)
 	grpcServer := grpc.NewServer(opts...)
 
 	handlers.UserService.RegisterHandler(grpcServer)
 	handlers.AuthService.RegisterHandler(grpcServer)
+	health.RegisterHandler(grpcServer)
+	if reflect {
+		reflection.Register(grpcServer)
+	}
 
 	return &Server{
 		gRPCServer: grpcServer,
+		health:     health,
+		drain:      drain,
 		port:       port,
 		log:        logger,
 	}
@@ -48,6 +62,7 @@ func (s *Server) Start() error {
 		s.log.String("port", s.port),
 	)
 
+	go s.health.Run()
 	if err = s.gRPCServer.Serve(l); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -56,7 +71,24 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Stop(ctx context.Context) error {
-	s.gRPCServer.GracefulStop()
+	s.health.Shutdown()
+	// give load balancers time to see NOT_SERVING before refusing calls
+	select {
+	case <-time.After(s.drain):
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.gRPCServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		// streams like health watches don't end by themselves
+		s.gRPCServer.Stop()
+	}
 	return nil
 }
 
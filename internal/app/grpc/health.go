@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"sync"
+	"time"
+)
+
+// Health serves grpc.health.v1. Every dependency is reported under its own
+// name, the server itself ("" and the names of its services) only as
+// SERVING while all dependencies are.
+type Health struct {
+	server   *health.Server
+	checks   map[string]func(ctx context.Context) error
+	services []string
+	conf     *config.HealthConfig
+	log      *logger.Logger
+
+	failing  map[string]bool
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewHealth(checks map[string]func(ctx context.Context) error, conf *config.HealthConfig, logger *logger.Logger) *Health {
+	server := health.NewServer()
+	// until the dependencies were checked
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return &Health{
+		server:  server,
+		checks:  checks,
+		conf:    conf,
+		log:     logger,
+		failing: make(map[string]bool),
+		done:    make(chan struct{}),
+	}
+}
+
+// RegisterHandler registers the health service, after the services it
+// reports on.
+func (h *Health) RegisterHandler(server *grpc.Server) {
+	for name := range server.GetServiceInfo() {
+		h.services = append(h.services, name)
+	}
+	healthpb.RegisterHealthServer(server, h.server)
+}
+
+// Run checks the dependencies until Shutdown.
+func (h *Health) Run() {
+	ticker := time.NewTicker(h.conf.CheckInterval)
+	defer ticker.Stop()
+	for {
+		h.check()
+		select {
+		case <-ticker.C:
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// Shutdown reports NOT_SERVING for good, so that load balancers stop
+// sending calls while the server drains.
+func (h *Health) Shutdown() {
+	h.stopOnce.Do(func() {
+		close(h.done)
+		h.server.Shutdown()
+	})
+}
+
+func (h *Health) check() {
+	serving := healthpb.HealthCheckResponse_SERVING
+	for name, check := range h.checks {
+		ctx, cancel := context.WithTimeout(context.Background(), h.conf.CheckTimeout)
+		err := check(ctx)
+		cancel()
+
+		st := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+			serving = st
+			if !h.failing[name] {
+				h.log.Warn("health check failed", h.log.String("dependency", name), h.log.String("error", err.Error()))
+			}
+		} else if h.failing[name] {
+			h.log.Info("health check recovered", h.log.String("dependency", name))
+		}
+		h.failing[name] = err != nil
+		h.server.SetServingStatus(name, st)
+	}
+	h.server.SetServingStatus("", serving)
+	for _, name := range h.services {
+		h.server.SetServingStatus(name, serving)
+	}
+}
@@ -1,19 +1,40 @@
 package grpc
 
 import (
+	"github.com/Roflan4eg/auth-serivce/config"
 	"github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/interceptors"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/ratelimit"
 	"google.golang.org/grpc"
 )
 
-func WithInterceptors(logger *logger.Logger) []grpc.ServerOption {
+// UnaryInterceptors returns the chain every call runs through, whether it
+// arrives over gRPC or through the HTTP gateway.
+func UnaryInterceptors(
+	logger *logger.Logger,
+	verifier interceptors.TokenVerifier,
+	policy interceptors.Policy,
+	limiter ratelimit.Limiter,
+	rateLimits *config.RateLimitConfig,
+	clients interceptors.ClientResolver,
+) []grpc.UnaryServerInterceptor {
+	return []grpc.UnaryServerInterceptor{
+		interceptors.Tracing(),
+		interceptors.Metrics(),
+		interceptors.RateLimit(limiter, rateLimits, clients),
+		interceptors.Validation(),
+		interceptors.Auth(verifier, logger),
+		interceptors.UserRateLimit(limiter, rateLimits, clients),
+		// after Auth, so every line is attributed to the calling user
+		interceptors.Logging(logger),
+		interceptors.Authorization(policy, logger),
+		interceptors.Recovery(logger),
+	}
+}
+
+func WithInterceptors(unary []grpc.UnaryServerInterceptor) []grpc.ServerOption {
 	return []grpc.ServerOption{
-		grpc.ChainUnaryInterceptor(
-			interceptors.Validation(),
-			interceptors.Logging(logger),
-			interceptors.Auth(),
-			interceptors.Recovery(logger),
-		),
+		grpc.ChainUnaryInterceptor(unary...),
 		//grpc.StreamInterceptor(
 		//	interceptors.StreamLoggingInterceptor(),
 		//),
@@ -1 +1,67 @@
 package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/interfaces/http/handlers"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"net"
+	"net/http"
+)
+
+type Server struct {
+	httpServer *http.Server
+	addr       string
+	log        *logger.Logger
+}
+
+func NewServer(
+	handlers *handlers.Container,
+	gateway *handlers.GatewayHandler,
+	logger *logger.Logger,
+	cfg *config.HTTPConfig,
+) *Server {
+	mux := http.NewServeMux()
+	handlers.JWKS.RegisterHandler(mux)
+	gateway.RegisterHandler(mux)
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Address(),
+			Handler:           mux,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+		},
+		addr: cfg.Address(),
+		log:  logger,
+	}
+}
+
+func (s *Server) Start() error {
+	const op = "httpserver.Run"
+	l, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("HTTP server starting",
+		s.log.String("addr", l.Addr().String()),
+	)
+
+	if err = s.httpServer.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+func (s *Server) Name() string {
+	return s.addr
+}
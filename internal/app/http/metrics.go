@@ -0,0 +1,46 @@
+package http
+
+import (
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+// metricsTimeout bounds a scrape, counting the sessions takes the longest.
+const metricsTimeout = 10 * time.Second
+
+// NewMetricsServer serves the metrics of gatherer for Prometheus.
+func NewMetricsServer(
+	gatherer prometheus.Gatherer,
+	logger *logger.Logger,
+	cfg *config.MetricsConfig,
+) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET "+cfg.Path, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{
+		ErrorLog: promLogger{logger},
+		Timeout:  metricsTimeout,
+	}))
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              cfg.Address(),
+			Handler:           mux,
+			ReadHeaderTimeout: metricsTimeout,
+			WriteTimeout:      metricsTimeout,
+		},
+		addr: cfg.Address(),
+		log:  logger,
+	}
+}
+
+// promLogger hands the errors of promhttp to the service logger.
+type promLogger struct {
+	log *logger.Logger
+}
+
+func (l promLogger) Println(v ...interface{}) {
+	l.log.Error("failed to serve metrics", l.log.Any("error", v))
+}
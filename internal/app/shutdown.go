@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
 	"os"
@@ -12,6 +13,9 @@ import (
 
 type Hook func(ctx context.Context) error
 
+// Closer runs the shutdown hooks one at a time, in reverse order of
+// registration, so what was set up last (the servers) stops before what it
+// depends on (storage, the tracer).
 type Closer struct {
 	mu    sync.Mutex
 	hooks []Hook
@@ -27,37 +31,17 @@ func (c *Closer) Close(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	errorCh := make(chan string, len(c.hooks))
-	readyCh := make(chan struct{}, 1)
-
-	wg := sync.WaitGroup{}
-	wg.Add(len(c.hooks))
-	go func() {
-		for _, hook := range c.hooks {
-			go func(hook Hook) {
-				defer wg.Done()
-				if err := hook(ctx); err != nil {
-					errorCh <- fmt.Sprintf("[!] %v\n", err)
-				}
-			}(hook)
+	var errString string
+	for i := len(c.hooks) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("shutdown cancelled: %v", err)
 		}
-		wg.Wait()
-		readyCh <- struct{}{}
-		close(errorCh)
-	}()
-
-	select {
-	case <-readyCh:
-		break
-	case sig := <-ctx.Done():
-		return fmt.Errorf("shutdown cancelled: %v", sig)
-	}
-	if len(errorCh) > 0 {
-		errString := fmt.Sprintf("Some shutdown hooks failed\n")
-		for err := range errorCh {
-			errString += err
+		if err := c.hooks[i](ctx); err != nil {
+			errString += fmt.Sprintf("[!] %v\n", err)
 		}
-		return fmt.Errorf(errString)
+	}
+	if errString != "" {
+		return errors.New("Some shutdown hooks failed\n" + errString)
 	}
 	return nil
 }
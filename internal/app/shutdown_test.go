@@ -0,0 +1,29 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCloser_RunsHooksInReverseOrder(t *testing.T) {
+	var order []string
+	hook := func(name string, err error) Hook {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	c := &Closer{}
+	c.Add(hook("tracer", nil))
+	c.Add(hook("storage", errors.New("pool busy")))
+	c.Add(hook("servers", nil))
+
+	err := c.Close(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pool busy")
+	// a failing hook doesn't keep the others from running
+	assert.Equal(t, []string{"servers", "storage", "tracer"}, order)
+}
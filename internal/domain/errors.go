@@ -5,11 +5,22 @@ import "errors"
 var (
 	ErrUserNotFound         = errors.New("user not found")
 	ErrUserAlreadyExists    = errors.New("user already exists")
+	ErrUserInactive         = errors.New("user account is deactivated")
 	ErrSessionNotFound      = errors.New("session not found")
 	ErrSessionAlreadyExists = errors.New("session already exists")
 	ErrSessionExpired       = errors.New("session expired or revoked")
+	ErrSessionRotated       = errors.New("session refresh token already rotated")
 	ErrInvalidCredentials   = errors.New("invalid credentials")
 	ErrEmailNotVerified     = errors.New("email not verified")
 	ErrInvalidPassword      = errors.New("invalid password")
 	ErrPermissionDenied     = errors.New("permission denied")
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleNotAssigned      = errors.New("role not assigned to user")
+	ErrActionTokenUsed      = errors.New("token already used or superseded")
+	ErrMFANotEnrolled       = errors.New("two-factor authentication is not enrolled")
+	ErrMFAAlreadyEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrInvalidMFACode       = errors.New("invalid two-factor authentication code")
+	ErrPasskeyNotFound      = errors.New("passkey not found")
+	ErrPasskeyExists        = errors.New("passkey already registered")
+	ErrCeremonyNotFound     = errors.New("passkey ceremony expired or already finished")
 )
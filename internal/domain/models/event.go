@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type SecurityEventType string
+
+const (
+	EventRefreshTokenReuse      SecurityEventType = "refresh_token_reuse"
+	EventPasswordCheckSucceeded SecurityEventType = "password_check_succeeded"
+	EventPasswordCheckFailed    SecurityEventType = "password_check_failed"
+	EventPasswordCheckThrottled SecurityEventType = "password_check_throttled"
+	EventMFAEnabled             SecurityEventType = "mfa_enabled"
+	EventMFADisabled            SecurityEventType = "mfa_disabled"
+	EventMFARecoveryCodeUsed    SecurityEventType = "mfa_recovery_code_used"
+	EventPasskeyRegistered      SecurityEventType = "passkey_registered"
+	EventPasskeyCloneDetected   SecurityEventType = "passkey_clone_detected"
+	EventAccountLocked          SecurityEventType = "account_locked"
+	EventAccountUnlocked        SecurityEventType = "account_unlocked"
+)
+
+type SecurityEvent struct {
+	Type       SecurityEventType `json:"type"`
+	UserID     string            `json:"user_id,omitempty"`
+	SessionID  string            `json:"session_id,omitempty"`
+	IpAddress  string            `json:"ip_address,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	Details    map[string]any    `json:"details,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
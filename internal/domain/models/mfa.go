@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// TOTP is the authenticator app enrolment of a user. Secret is encrypted,
+// the enrolment only protects logins once EnabledAt is set.
+type TOTP struct {
+	UserID       string     `db:"user_id"`
+	Secret       []byte     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+func (t *TOTP) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+type TOTPEnrollment struct {
+	Secret string
+	URL    string
+}
+
+// MFAChallenge is returned by Login instead of a session when the user has
+// two-factor authentication enabled.
+type MFAChallenge struct {
+	Token     string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Passkey is a WebAuthn credential registered by a user.
+type Passkey struct {
+	ID              []byte     `db:"id"`
+	UserID          string     `db:"user_id"`
+	PublicKey       []byte     `db:"public_key"`
+	AttestationType string     `db:"attestation_type"`
+	AAGUID          []byte     `db:"aaguid"`
+	SignCount       uint32     `db:"sign_count"`
+	Transports      []string   `db:"transports"`
+	BackupEligible  bool       `db:"backup_eligible"`
+	BackupState     bool       `db:"backup_state"`
+	CreatedAt       time.Time  `db:"created_at"`
+	LastUsedAt      *time.Time `db:"last_used_at"`
+}
+
+// PasskeyCeremony is a begun registration or login. Options is the JSON the
+// client passes to navigator.credentials, the ceremony is finished by
+// sending back its ID with the authenticator response.
+type PasskeyCeremony struct {
+	ID        string
+	Options   []byte
+	ExpiresAt time.Time
+}
@@ -0,0 +1,19 @@
+package models
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    string
+	SessionID string
+	Roles     []string
+	// Scopes are the permissions granted to the caller.
+	Scopes []string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
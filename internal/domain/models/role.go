@@ -0,0 +1,23 @@
+package models
+
+const (
+	RoleAdmin   = "admin"
+	RoleService = "service"
+)
+
+const (
+	PermUsersCreate           = "users:create"
+	PermUsersRead             = "users:read"
+	PermUsersUpdate           = "users:update"
+	PermUsersDeactivate       = "users:deactivate"
+	PermUsersValidatePassword = "users:validate_password"
+	PermSessionsRead          = "sessions:read"
+	PermSessionsRevoke        = "sessions:revoke"
+	PermRolesManage           = "roles:manage"
+)
+
+// UserAccess is what a user is allowed to do, resolved at token issue time.
+type UserAccess struct {
+	Roles       []string
+	Permissions []string
+}
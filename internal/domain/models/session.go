@@ -15,9 +15,30 @@ type Session struct {
 	RefreshToken     string    `json:"refresh_token"`
 	UserAgent        string    `json:"user_agent"`
 	IpAddress        string    `json:"ip_address"`
+	DeviceID         string    `json:"device_id"`
+	DeviceName       string    `json:"device_name"`
+	// The session is the refresh token family: every rotation replaces
+	// RefreshToken and remembers the token it replaced, so a rotated-out token
+	// presented again can be recognised as reuse.
+	PreviousRefreshToken string    `json:"-"`
+	RotatedAt            time.Time `json:"rotated_at"`
 	//IsRevoked        bool      `json:"is_revoked"`
 }
 
+// ClientInfo describes the client a session is created for.
+type ClientInfo struct {
+	IpAddress  string
+	UserAgent  string
+	DeviceID   string
+	DeviceName string
+}
+
+type SessionPage struct {
+	Sessions      []*Session `json:"sessions"`
+	NextPageToken string     `json:"next_page_token"`
+	Total         int        `json:"total"`
+}
+
 type JWTClaims struct {
 	UserID    string `json:"user_id"`
 	SessionID string `json:"session_id"`
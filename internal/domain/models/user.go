@@ -5,10 +5,19 @@ import (
 	"time"
 )
 
+// User is an account. Password holds the hash and is nil for users who sign
+// in without a password.
 type User struct {
-	ID        uuid.UUID `db:"id"`
-	Email     string    `db:"email"`
-	Password  []byte    `db:"password"`
-	CreatedAt time.Time `db:"created_at"`
-	IsActive  bool      `db:"is_active"`
+	ID                 uuid.UUID  `db:"id"`
+	Email              string     `db:"email"`
+	Password           []byte     `db:"password"`
+	CreatedAt          time.Time  `db:"created_at"`
+	IsActive           bool       `db:"is_active"`
+	DeactivatedAt      *time.Time `db:"deactivated_at"`
+	DeactivationReason string     `db:"deactivation_reason"`
+	EmailVerifiedAt    *time.Time `db:"email_verified_at"`
+}
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
@@ -2,14 +2,25 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
 	"github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/authctx"
 	"github.com/Roflan4eg/auth-serivce/internal/services"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type AuthGRPCHandler struct {
-	authService *services.AuthService
+	authService    *services.AuthService
+	accountService *services.AccountService
+	mfaService     *services.MFAService
+	passkeyService *services.PasskeyService
+	loginService   *services.PasswordlessService
+	clients        *ClientResolver
 	pb.UnimplementedAuthServiceServer
 }
 
@@ -17,30 +28,68 @@ func (h *AuthGRPCHandler) RegisterHandler(server *grpc.Server) {
 	pb.RegisterAuthServiceServer(server, h)
 }
 
-func NewAuthGRPCHandler(authService *services.AuthService) *AuthGRPCHandler {
-	return &AuthGRPCHandler{authService: authService}
+func NewAuthGRPCHandler(
+	authService *services.AuthService,
+	accountService *services.AccountService,
+	mfaService *services.MFAService,
+	passkeyService *services.PasskeyService,
+	loginService *services.PasswordlessService,
+	clients *ClientResolver,
+) *AuthGRPCHandler {
+	return &AuthGRPCHandler{
+		authService:    authService,
+		accountService: accountService,
+		mfaService:     mfaService,
+		passkeyService: passkeyService,
+		loginService:   loginService,
+		clients:        clients,
+	}
 }
 
 func (h *AuthGRPCHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.SessionResponse, error) {
-	user, err := h.authService.Register(ctx, req.GetEmail(), req.GetPassword())
+	client := h.clients.Resolve(ctx)
+	client.DeviceID, client.DeviceName = req.GetDeviceId(), req.GetDeviceName()
+	ses, err := h.authService.Register(ctx, req.GetEmail(), req.GetPassword(), client)
 	if err != nil {
 		return nil, err
 	}
-	resp := &pb.SessionResponse{AccessToken: user.AccessToken, RefreshToken: user.RefreshToken}
+	if ses == nil {
+		// silent duplicate registration, the user continues from the mail
+		return &pb.SessionResponse{}, nil
+	}
+	resp := &pb.SessionResponse{AccessToken: ses.AccessToken, RefreshToken: ses.RefreshToken}
 	return resp, nil
 }
 
 func (h *AuthGRPCHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.SessionResponse, error) {
-	ses, err := h.authService.Login(ctx, req.GetEmail(), req.GetPassword())
+	client := h.clients.Resolve(ctx)
+	client.DeviceID, client.DeviceName = req.GetDeviceId(), req.GetDeviceName()
+	ses, challenge, err := h.authService.Login(ctx, req.GetEmail(), req.GetPassword(), client)
 	if err != nil {
 		return nil, err
 	}
-	resp := &pb.SessionResponse{AccessToken: ses.AccessToken, RefreshToken: ses.RefreshToken}
-	return resp, nil
+	return toSessionResponse(ses, challenge), nil
+}
+
+// toSessionResponse answers a login, which yields either a session or the
+// challenge for the second factor.
+func toSessionResponse(ses *models.Session, challenge *models.MFAChallenge) *pb.SessionResponse {
+	if challenge != nil {
+		return &pb.SessionResponse{
+			MfaRequired:  true,
+			MfaToken:     challenge.Token,
+			MfaExpiresAt: timestamppb.New(challenge.ExpiresAt),
+		}
+	}
+	return &pb.SessionResponse{AccessToken: ses.AccessToken, RefreshToken: ses.RefreshToken}
 }
 
 func (h *AuthGRPCHandler) Logout(ctx context.Context, req *pb.LogoutRequest) (*emptypb.Empty, error) {
-	if err := h.authService.Logout(ctx, req.GetAccessToken()); err != nil {
+	principal, ok := authctx.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if err := h.authService.Logout(ctx, principal.SessionID); err != nil {
 		return &emptypb.Empty{}, err
 	}
 	return &emptypb.Empty{}, nil
@@ -56,7 +105,7 @@ func (h *AuthGRPCHandler) RefreshToken(ctx context.Context, req *pb.RefreshToken
 }
 
 func (h *AuthGRPCHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
-	validRes := h.authService.ValidateToken(ctx, req.GetToken())
+	validRes := h.authService.ValidateToken(ctx, req.GetToken(), req.GetAudience())
 
 	resp := &pb.ValidateTokenResponse{
 		Valid:     validRes.Valid,
@@ -67,6 +116,203 @@ func (h *AuthGRPCHandler) ValidateToken(ctx context.Context, req *pb.ValidateTok
 	return resp, nil
 }
 
-//func (h *AuthGRPCHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
-//
-//}
+func (h *AuthGRPCHandler) GetSession(ctx context.Context, req *pb.GetSessionRequest) (*pb.SessionInfo, error) {
+	ses, err := h.authService.GetSession(ctx, req.GetSessionId())
+	if err != nil {
+		return nil, err
+	}
+	return toSessionInfo(ses), nil
+}
+
+func (h *AuthGRPCHandler) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	page, err := h.authService.ListSessions(ctx, req.GetUserId(), int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.ListSessionsResponse{
+		Sessions:      make([]*pb.SessionInfo, 0, len(page.Sessions)),
+		NextPageToken: page.NextPageToken,
+		Total:         int32(page.Total),
+	}
+	for _, ses := range page.Sessions {
+		resp.Sessions = append(resp.Sessions, toSessionInfo(ses))
+	}
+	return resp, nil
+}
+
+func (h *AuthGRPCHandler) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*emptypb.Empty, error) {
+	if err := h.authService.RevokeSession(ctx, req.GetSessionId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthGRPCHandler) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*emptypb.Empty, error) {
+	if err := h.authService.RevokeAllSessions(ctx, req.GetUserId(), req.GetExceptSessionId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toSessionInfo(ses *models.Session) *pb.SessionInfo {
+	return &pb.SessionInfo{
+		Id:               ses.ID,
+		UserId:           ses.UserID,
+		CreatedAt:        timestamppb.New(ses.CreatedAt),
+		ExpiresAt:        timestamppb.New(ses.ExpiresAt),
+		RefreshExpiresAt: timestamppb.New(ses.RefreshExpiresAt),
+		UserAgent:        ses.UserAgent,
+		IpAddress:        ses.IpAddress,
+		DeviceId:         ses.DeviceID,
+		DeviceName:       ses.DeviceName,
+	}
+}
+
+func (h *AuthGRPCHandler) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*emptypb.Empty, error) {
+	if err := h.accountService.VerifyEmail(ctx, req.GetToken()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthGRPCHandler) ResendVerificationEmail(ctx context.Context, req *pb.ResendVerificationEmailRequest) (*emptypb.Empty, error) {
+	if err := h.accountService.ResendEmailVerification(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthGRPCHandler) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*emptypb.Empty, error) {
+	if err := h.accountService.RequestPasswordReset(ctx, req.GetEmail()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthGRPCHandler) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*emptypb.Empty, error) {
+	if err := h.accountService.ResetPassword(ctx, req.GetToken(), req.GetNewPassword()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthGRPCHandler) EnrollTOTP(ctx context.Context, _ *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	principal, ok := authctx.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	enrollment, err := h.mfaService.EnrollTOTP(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.EnrollTOTPResponse{Secret: enrollment.Secret, OtpauthUrl: enrollment.URL}, nil
+}
+
+func (h *AuthGRPCHandler) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	principal, ok := authctx.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	recoveryCodes, err := h.mfaService.ConfirmTOTP(ctx, principal.UserID, req.GetCode())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ConfirmTOTPResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+func (h *AuthGRPCHandler) DisableTOTP(ctx context.Context, req *pb.DisableTOTPRequest) (*emptypb.Empty, error) {
+	principal, ok := authctx.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	if err := h.mfaService.DisableTOTP(ctx, principal.UserID, req.GetCode()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthGRPCHandler) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.SessionResponse, error) {
+	client := h.clients.Resolve(ctx)
+	client.DeviceID, client.DeviceName = req.GetDeviceId(), req.GetDeviceName()
+	ses, err := h.authService.VerifyMFA(ctx, req.GetMfaToken(), req.GetCode(), client)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SessionResponse{AccessToken: ses.AccessToken, RefreshToken: ses.RefreshToken}, nil
+}
+
+func (h *AuthGRPCHandler) BeginPasskeyRegistration(ctx context.Context, _ *pb.BeginPasskeyRegistrationRequest) (*pb.PasskeyCeremonyResponse, error) {
+	principal, ok := authctx.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	ceremony, err := h.passkeyService.BeginRegistration(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return toPasskeyCeremonyResponse(ceremony), nil
+}
+
+func (h *AuthGRPCHandler) FinishPasskeyRegistration(ctx context.Context, req *pb.FinishPasskeyRegistrationRequest) (*pb.PasskeyInfo, error) {
+	principal, ok := authctx.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authentication required")
+	}
+	passkey, err := h.passkeyService.FinishRegistration(ctx, principal.UserID, req.GetCeremonyId(), []byte(req.GetCredential()))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.PasskeyInfo{
+		Id:             base64.RawURLEncoding.EncodeToString(passkey.ID),
+		Transports:     passkey.Transports,
+		BackupEligible: passkey.BackupEligible,
+		CreatedAt:      timestamppb.New(passkey.CreatedAt),
+	}, nil
+}
+
+func (h *AuthGRPCHandler) BeginPasskeyLogin(ctx context.Context, _ *pb.BeginPasskeyLoginRequest) (*pb.PasskeyCeremonyResponse, error) {
+	ceremony, err := h.passkeyService.BeginLogin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toPasskeyCeremonyResponse(ceremony), nil
+}
+
+func (h *AuthGRPCHandler) FinishPasskeyLogin(ctx context.Context, req *pb.FinishPasskeyLoginRequest) (*pb.SessionResponse, error) {
+	client := h.clients.Resolve(ctx)
+	client.DeviceID, client.DeviceName = req.GetDeviceId(), req.GetDeviceName()
+	ses, err := h.authService.LoginWithPasskey(ctx, req.GetCeremonyId(), []byte(req.GetCredential()), client)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SessionResponse{AccessToken: ses.AccessToken, RefreshToken: ses.RefreshToken}, nil
+}
+
+func (h *AuthGRPCHandler) StartPasswordlessLogin(ctx context.Context, req *pb.StartPasswordlessLoginRequest) (*emptypb.Empty, error) {
+	mode := services.PasswordlessLink
+	if req.GetMode() == pb.PasswordlessMode_PASSWORDLESS_MODE_CODE {
+		mode = services.PasswordlessCode
+	}
+	if err := h.loginService.Start(ctx, req.GetEmail(), mode); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *AuthGRPCHandler) CompletePasswordlessLogin(ctx context.Context, req *pb.CompletePasswordlessLoginRequest) (*pb.SessionResponse, error) {
+	client := h.clients.Resolve(ctx)
+	client.DeviceID, client.DeviceName = req.GetDeviceId(), req.GetDeviceName()
+	ses, challenge, err := h.authService.LoginPasswordless(ctx, req.GetToken(), req.GetEmail(), req.GetCode(), client)
+	if err != nil {
+		return nil, err
+	}
+	return toSessionResponse(ses, challenge), nil
+}
+
+func toPasskeyCeremonyResponse(ceremony *models.PasskeyCeremony) *pb.PasskeyCeremonyResponse {
+	return &pb.PasskeyCeremonyResponse{
+		CeremonyId: ceremony.ID,
+		Options:    string(ceremony.Options),
+		ExpiresAt:  timestamppb.New(ceremony.ExpiresAt),
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// ClientResolver extracts the client address and user agent of a call.
+// x-forwarded-for is only taken into account when the direct peer is a trusted
+// proxy; the header is then walked from the right and the first address that is
+// not a trusted proxy itself is the client.
+type ClientResolver struct {
+	trusted []netip.Prefix
+}
+
+func NewClientResolver(trustedProxies []string) (*ClientResolver, error) {
+	r := &ClientResolver{}
+	for _, p := range trustedProxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, err
+			}
+			r.trusted = append(r.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, err
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+func (r *ClientResolver) Resolve(ctx context.Context) models.ClientInfo {
+	var info models.ClientInfo
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	info.UserAgent = firstValue(md, "grpcgateway-user-agent", "user-agent")
+
+	addr, ok := peerAddr(ctx)
+	if !ok {
+		return info
+	}
+	if r.isTrusted(addr) {
+		forwarded := md.Get("x-forwarded-for")
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				break
+			}
+			addr = hop.Unmap()
+			if !r.isTrusted(addr) {
+				break
+			}
+		}
+	}
+	info.IpAddress = addr.String()
+	return info
+}
+
+func (r *ClientResolver) isTrusted(addr netip.Addr) bool {
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func peerAddr(ctx context.Context) (netip.Addr, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return netip.Addr{}, false
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func firstValue(md metadata.MD, keys ...string) string {
+	for _, key := range keys {
+		if v := md.Get(key); len(v) > 0 && v[0] != "" {
+			return v[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+)
+
+func TestClientResolver_Resolve(t *testing.T) {
+	resolver, err := NewClientResolver([]string{"10.0.0.0/8", "127.0.0.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		peerAddr  string
+		forwarded string
+		wantIP    string
+	}{
+		{
+			name:     "direct connection",
+			peerAddr: "203.0.113.7:51000",
+			wantIP:   "203.0.113.7",
+		},
+		{
+			name:      "untrusted peer cannot spoof the header",
+			peerAddr:  "203.0.113.7:51000",
+			forwarded: "198.51.100.1",
+			wantIP:    "203.0.113.7",
+		},
+		{
+			name:      "trusted proxy",
+			peerAddr:  "10.1.2.3:51000",
+			forwarded: "198.51.100.1",
+			wantIP:    "198.51.100.1",
+		},
+		{
+			name:      "chain of trusted proxies",
+			peerAddr:  "127.0.0.1:51000",
+			forwarded: "6.6.6.6, 198.51.100.1, 10.0.0.5",
+			wantIP:    "198.51.100.1",
+		},
+		{
+			name:      "ipv4 mapped peer",
+			peerAddr:  "[::ffff:10.1.2.3]:51000",
+			forwarded: "198.51.100.1",
+			wantIP:    "198.51.100.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, err := net.ResolveTCPAddr("tcp", tc.peerAddr)
+			require.NoError(t, err)
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+			md := metadata.Pairs("user-agent", "test-agent/1.0")
+			if tc.forwarded != "" {
+				md.Append("x-forwarded-for", tc.forwarded)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			info := resolver.Resolve(ctx)
+			assert.Equal(t, tc.wantIP, info.IpAddress)
+			assert.Equal(t, "test-agent/1.0", info.UserAgent)
+		})
+	}
+}
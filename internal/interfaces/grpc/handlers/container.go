@@ -9,6 +9,7 @@ import (
 type Container struct {
 	UserService *UserGRPCHandler
 	AuthService *AuthGRPCHandler
+	Clients     *ClientResolver
 }
 
 func NewContainer(
@@ -17,11 +18,23 @@ func NewContainer(
 	logger *logger.Logger,
 ) *Container {
 
-	userHandler := NewUserGRPCHandler(services.UserService)
-	authHandler := NewAuthGRPCHandler(services.AuthService)
+	clients, err := NewClientResolver(cfg.GRPC.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+	userHandler := NewUserGRPCHandler(services.UserService, services.RoleService, services.AccountService, clients)
+	authHandler := NewAuthGRPCHandler(
+		services.AuthService,
+		services.AccountService,
+		services.MFAService,
+		services.PasskeyService,
+		services.PasswordlessService,
+		clients,
+	)
 
 	return &Container{
 		UserService: userHandler,
 		AuthService: authHandler,
+		Clients:     clients,
 	}
 }
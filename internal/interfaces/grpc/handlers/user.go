@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
 	"github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
 	"github.com/Roflan4eg/auth-serivce/internal/services"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type UserGRPCHandler struct {
-	userService *services.UserService
+	userService    *services.UserService
+	roleService    *services.RoleService
+	accountService *services.AccountService
+	clients        *ClientResolver
 	pb.UnimplementedUserServiceServer
 }
 
@@ -17,8 +22,18 @@ func (h *UserGRPCHandler) RegisterHandler(server *grpc.Server) {
 	pb.RegisterUserServiceServer(server, h)
 }
 
-func NewUserGRPCHandler(userService *services.UserService) *UserGRPCHandler {
-	return &UserGRPCHandler{userService: userService}
+func NewUserGRPCHandler(
+	userService *services.UserService,
+	roleService *services.RoleService,
+	accountService *services.AccountService,
+	clients *ClientResolver,
+) *UserGRPCHandler {
+	return &UserGRPCHandler{
+		userService:    userService,
+		roleService:    roleService,
+		accountService: accountService,
+		clients:        clients,
+	}
 }
 
 func (h *UserGRPCHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.UserResponse, error) {
@@ -26,7 +41,7 @@ func (h *UserGRPCHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequ
 	if err != nil {
 		return nil, err
 	}
-	return &pb.UserResponse{Id: user.ID.String(), Email: user.Email, IsActive: user.IsActive}, nil
+	return toUserResponse(user), nil
 }
 
 func (h *UserGRPCHandler) GetUserById(ctx context.Context, req *pb.GetUserRequest) (*pb.UserResponse, error) {
@@ -34,7 +49,7 @@ func (h *UserGRPCHandler) GetUserById(ctx context.Context, req *pb.GetUserReques
 	if err != nil {
 		return nil, err
 	}
-	return &pb.UserResponse{Id: user.ID.String(), Email: user.Email, IsActive: user.IsActive}, nil
+	return toUserResponse(user), nil
 }
 
 func (h *UserGRPCHandler) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.UserResponse, error) {
@@ -42,7 +57,23 @@ func (h *UserGRPCHandler) GetUserByEmail(ctx context.Context, req *pb.GetUserByE
 	if err != nil {
 		return nil, err
 	}
-	return &pb.UserResponse{Id: user.ID.String(), Email: user.Email, IsActive: user.IsActive}, nil
+	return toUserResponse(user), nil
+}
+
+func (h *UserGRPCHandler) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	user, pending, err := h.accountService.RequestEmailChange(ctx, req.GetUserId(), req.GetEmail())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateUserResponse{User: toUserResponse(user), EmailChangePending: pending}, nil
+}
+
+func (h *UserGRPCHandler) ConfirmEmailChange(ctx context.Context, req *pb.ConfirmEmailChangeRequest) (*pb.UserResponse, error) {
+	user, err := h.accountService.ConfirmEmailChange(ctx, req.GetToken())
+	if err != nil {
+		return nil, err
+	}
+	return toUserResponse(user), nil
 }
 
 func (h *UserGRPCHandler) UpdateUserPassword(ctx context.Context, req *pb.UpdateUserPasswordRequest) (*emptypb.Empty, error) {
@@ -52,3 +83,60 @@ func (h *UserGRPCHandler) UpdateUserPassword(ctx context.Context, req *pb.Update
 	}
 	return &emptypb.Empty{}, nil
 }
+
+func (h *UserGRPCHandler) DeactivateUser(ctx context.Context, req *pb.DeactivateUserRequest) (*emptypb.Empty, error) {
+	if err := h.userService.DeactivateUser(ctx, req.GetUserId(), req.GetReason()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserGRPCHandler) ReactivateUser(ctx context.Context, req *pb.ReactivateUserRequest) (*emptypb.Empty, error) {
+	if err := h.userService.ReactivateUser(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserGRPCHandler) UnlockUser(ctx context.Context, req *pb.UnlockUserRequest) (*emptypb.Empty, error) {
+	if err := h.userService.UnlockUser(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserGRPCHandler) ValidatePassword(ctx context.Context, req *pb.ValidatePasswordRequest) (*pb.ValidatePasswordResponse, error) {
+	valid, err := h.userService.ValidatePassword(ctx, req.GetUserId(), req.GetPassword(), h.clients.Resolve(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ValidatePasswordResponse{IsValid: valid}, nil
+}
+
+func (h *UserGRPCHandler) AssignRole(ctx context.Context, req *pb.AssignRoleRequest) (*emptypb.Empty, error) {
+	if err := h.roleService.AssignRole(ctx, req.GetUserId(), req.GetRole()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *UserGRPCHandler) RevokeRole(ctx context.Context, req *pb.RevokeRoleRequest) (*emptypb.Empty, error) {
+	if err := h.roleService.RevokeRole(ctx, req.GetUserId(), req.GetRole()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func toUserResponse(user *models.User) *pb.UserResponse {
+	resp := &pb.UserResponse{
+		Id:                 user.ID.String(),
+		Email:              user.Email,
+		IsActive:           user.IsActive,
+		DeactivationReason: user.DeactivationReason,
+		EmailVerified:      user.EmailVerified(),
+	}
+	if user.DeactivatedAt != nil {
+		resp.DeactivatedAt = timestamppb.New(*user.DeactivatedAt)
+	}
+	return resp
+}
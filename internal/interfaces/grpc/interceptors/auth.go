@@ -2,19 +2,39 @@ package interceptors
 
 import (
 	"context"
+	"errors"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/authctx"
+	l "github.com/Roflan4eg/auth-serivce/internal/lib/logger"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"strings"
 )
 
-func Auth() grpc.UnaryServerInterceptor {
+var (
+	errMissingToken = errors.New("missing bearer token")
+)
+
+type TokenVerifier interface {
+	Authenticate(ctx context.Context, accessToken string) (*models.Principal, error)
+}
+
+func Auth(verifier TokenVerifier, logger *l.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		if isPublicMethod(info.FullMethod) {
 			return handler(ctx, req)
 		}
 
-		authenticatedCtx, err := authenticate(ctx)
+		authenticatedCtx, err := authenticate(ctx, verifier)
 		if err != nil {
+			// Logging runs after Auth, so the failure is only logged here
+			logger.WarnContext(l.WithMethod(ctx, info.FullMethod), "authentication failed", logger.String("error", err.Error()))
+			if errors.Is(err, domain.ErrUserInactive) {
+				return nil, status.Error(codes.FailedPrecondition, err.Error())
+			}
 			return nil, status.Error(codes.Unauthenticated, "authentication failed")
 		}
 
@@ -24,14 +44,55 @@ func Auth() grpc.UnaryServerInterceptor {
 
 func isPublicMethod(method string) bool {
 	publicMethods := map[string]bool{
-		"/auth.AuthService/Login":    true,
-		"/auth.AuthService/Register": true,
-		"/auth.AuthService/Health":   true,
+		"/auth.AuthService/Login":                  true,
+		"/auth.AuthService/Register":               true,
+		"/auth.AuthService/RefreshToken":           true,
+		"/auth.AuthService/ValidateToken":          true,
+		"/auth.AuthService/RequestPasswordReset":   true,
+		"/auth.AuthService/BeginPasskeyLogin":      true,
+		"/auth.AuthService/StartPasswordlessLogin": true,
+		// authenticated by the challenge returned by Login
+		"/auth.AuthService/VerifyMFA": true,
+		// authenticated by the passkey signing the challenge
+		"/auth.AuthService/FinishPasskeyLogin": true,
+		// authenticated by the token sent by mail
+		"/auth.AuthService/VerifyEmail":               true,
+		"/auth.AuthService/ResetPassword":             true,
+		"/auth.AuthService/CompletePasswordlessLogin": true,
+		"/user.UserService/ConfirmEmailChange":        true,
+		// probed by load balancers and orchestrators
+		"/grpc.health.v1.Health/Check": true,
+		"/grpc.health.v1.Health/List":  true,
 	}
 	return publicMethods[method]
 }
 
-func authenticate(ctx context.Context) (context.Context, error) {
-	// TODO
+func authenticate(ctx context.Context, verifier TokenVerifier) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	principal, err := verifier.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	ctx = authctx.WithPrincipal(ctx, principal)
+	ctx = l.WithUserID(ctx, principal.UserID)
 	return ctx, nil
 }
+
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", errMissingToken
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", errMissingToken
+	}
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "bearer") || strings.TrimSpace(token) == "" {
+		return "", errMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
@@ -0,0 +1,66 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/authctx"
+	l "github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Rule describes who may call a method. A caller holding all Permissions is
+// let through. Otherwise, if Owner is set and resolves the request to the
+// caller's own user ID, the call is a self-service call and allowed as well.
+type Rule struct {
+	Permissions []string
+	Owner       func(ctx context.Context, req any) (string, error)
+}
+
+// Policy maps gRPC full method names to their rules. Authenticated methods
+// missing from the policy are denied.
+type Policy map[string]Rule
+
+func Authorization(policy Policy, logger *l.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		principal, ok := authctx.PrincipalFromContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		rule, ok := policy[info.FullMethod]
+		if !ok || !rule.allows(ctx, principal, req) {
+			logger.WarnContext(ctx, "permission denied",
+				logger.String("method", info.FullMethod),
+				logger.Any("roles", principal.Roles),
+			)
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func (r Rule) allows(ctx context.Context, principal *models.Principal, req any) bool {
+	if hasAll(principal, r.Permissions) {
+		return true
+	}
+	if r.Owner == nil {
+		return false
+	}
+	owner, err := r.Owner(ctx, req)
+	return err == nil && owner != "" && owner == principal.UserID
+}
+
+func hasAll(principal *models.Principal, permissions []string) bool {
+	for _, p := range permissions {
+		if !principal.HasScope(p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/authctx"
+	l "github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+type ownedRequest struct {
+	userID string
+}
+
+func TestAuthorization(t *testing.T) {
+	logger := &l.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	policy := Policy{
+		"/test.Service/Owned": {
+			Permissions: []string{"users:read"},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*ownedRequest).userID, nil
+			},
+		},
+		"/test.Service/AdminOnly": {
+			Permissions: []string{"roles:manage"},
+		},
+		"/test.Service/AnyUser": {},
+	}
+	interceptor := Authorization(policy, logger)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		principal *models.Principal
+		req       any
+		wantCode  codes.Code
+	}{
+		{
+			name:      "self service",
+			method:    "/test.Service/Owned",
+			principal: &models.Principal{UserID: "u1"},
+			req:       &ownedRequest{userID: "u1"},
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "other user without permission",
+			method:    "/test.Service/Owned",
+			principal: &models.Principal{UserID: "u1"},
+			req:       &ownedRequest{userID: "u2"},
+			wantCode:  codes.PermissionDenied,
+		},
+		{
+			name:      "other user with permission",
+			method:    "/test.Service/Owned",
+			principal: &models.Principal{UserID: "u1", Scopes: []string{"users:read"}},
+			req:       &ownedRequest{userID: "u2"},
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "admin only without permission",
+			method:    "/test.Service/AdminOnly",
+			principal: &models.Principal{UserID: "u1", Scopes: []string{"users:read"}},
+			req:       &ownedRequest{userID: "u1"},
+			wantCode:  codes.PermissionDenied,
+		},
+		{
+			name:      "any authenticated user",
+			method:    "/test.Service/AnyUser",
+			principal: &models.Principal{UserID: "u1"},
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "method missing from policy",
+			method:    "/test.Service/Unknown",
+			principal: &models.Principal{UserID: "u1", Scopes: []string{"roles:manage"}},
+			wantCode:  codes.PermissionDenied,
+		},
+		{
+			name:     "no principal",
+			method:   "/test.Service/AnyUser",
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.principal != nil {
+				ctx = authctx.WithPrincipal(ctx, tc.principal)
+			}
+			_, err := interceptor(ctx, tc.req, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			assert.Equal(t, tc.wantCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package interceptors
+
+import (
+	"context"
+	"google.golang.org/grpc"
+)
+
+// Chain combines interceptors into one, the first being the outermost, like
+// grpc.ChainUnaryInterceptor does for a server. It lets callers outside a
+// grpc.Server, such as the HTTP gateway, run the same chain.
+func Chain(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return chained(interceptors, 0, info, handler)(ctx, req)
+	}
+}
+
+func chained(interceptors []grpc.UnaryServerInterceptor, i int, info *grpc.UnaryServerInfo, final grpc.UnaryHandler) grpc.UnaryHandler {
+	if i == len(interceptors) {
+		return final
+	}
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		return interceptors[i](ctx, req, info, chained(interceptors, i+1, info, final))
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/Roflan4eg/auth-serivce/internal/services"
 
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"time"
 )
 
@@ -83,21 +85,60 @@ func translateCustomError(err error) error {
 		services.ErrInvalidRefreshToken: codes.Unauthenticated,
 		services.ErrTokenExpired:        codes.Unauthenticated,
 		services.ErrTokenMalformed:      codes.Unauthenticated,
-		services.ErrTokenExpired:        codes.Unauthenticated,
+		services.ErrRefreshTokenReused:  codes.Unauthenticated,
 		domain.ErrSessionExpired:        codes.Unauthenticated,
 		domain.ErrSessionNotFound:       codes.NotFound,
 		domain.ErrUserNotFound:          codes.NotFound,
+		domain.ErrUserInactive:          codes.FailedPrecondition,
+		domain.ErrEmailNotVerified:      codes.FailedPrecondition,
 		domain.ErrSessionAlreadyExists:  codes.AlreadyExists,
 		domain.ErrUserAlreadyExists:     codes.AlreadyExists,
 		domain.ErrPermissionDenied:      codes.PermissionDenied,
+		domain.ErrRoleNotFound:          codes.NotFound,
+		domain.ErrRoleNotAssigned:       codes.NotFound,
 		domain.ErrInvalidPassword:       codes.InvalidArgument,
+		domain.ErrInvalidCredentials:    codes.Unauthenticated,
+		services.ErrInvalidPageToken:    codes.InvalidArgument,
+		services.ErrTooManyAttempts:     codes.ResourceExhausted,
+		services.ErrInvalidActionToken:  codes.InvalidArgument,
+		domain.ErrActionTokenUsed:       codes.InvalidArgument,
+		services.ErrMFANotConfigured:    codes.FailedPrecondition,
+		domain.ErrMFANotEnrolled:        codes.FailedPrecondition,
+		domain.ErrMFAAlreadyEnabled:     codes.AlreadyExists,
+		domain.ErrInvalidMFACode:        codes.InvalidArgument,
+		services.ErrInvalidPasskey:      codes.Unauthenticated,
+		domain.ErrPasskeyNotFound:       codes.NotFound,
+		domain.ErrPasskeyExists:         codes.AlreadyExists,
+		domain.ErrCeremonyNotFound:      codes.FailedPrecondition,
+		services.ErrInvalidLoginCode:    codes.InvalidArgument,
+		services.ErrLoginDelayed:        codes.ResourceExhausted,
+		// unlike an inactive account the lock lifts, RetryInfo tells when
+		services.ErrAccountLocked: codes.Unavailable,
 	}
 	originalErr := l.OriginalError(err)
 	for knownErr, code := range errorMapping {
 		if errors.Is(originalErr, knownErr) {
-			return status.Errorf(code, err.Error())
+			return withRetryInfo(status.New(code, err.Error()), originalErr).Err()
 		}
 	}
 
 	return nil
 }
+
+// withRetryInfo tells clients when a call refused with a
+// *services.RetryAfterError may be retried.
+func withRetryInfo(st *status.Status, err error) *status.Status {
+	var retryErr *services.RetryAfterError
+	if !errors.As(err, &retryErr) {
+		return st
+	}
+	return retryInfo(st, retryErr.RetryAfter)
+}
+
+func retryInfo(st *status.Status, retryAfter time.Duration) *status.Status {
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st
+	}
+	return detailed
+}
@@ -0,0 +1,34 @@
+package interceptors
+
+import (
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestTranslateError_LockoutIsDistinct(t *testing.T) {
+	locked := translateError(&services.RetryAfterError{Err: services.ErrAccountLocked, RetryAfter: time.Minute})
+	delayed := translateError(&services.RetryAfterError{Err: services.ErrLoginDelayed, RetryAfter: time.Second})
+	inactive := translateError(domain.ErrUserInactive)
+	unverified := translateError(domain.ErrEmailNotVerified)
+
+	assert.Equal(t, codes.Unavailable, status.Code(locked))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(delayed))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(inactive))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(unverified))
+
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range status.Convert(locked).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	require.NotNil(t, retryInfo)
+	assert.Equal(t, time.Minute, retryInfo.GetRetryDelay().AsDuration())
+}
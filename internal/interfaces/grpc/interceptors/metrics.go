@@ -0,0 +1,31 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+// Metrics records the count, duration, request size and errors of every
+// call by method and gRPC status code. It runs before every interceptor that
+// can refuse a call, so refused calls are counted as well.
+func Metrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		if msg, ok := req.(proto.Message); ok {
+			metrics.GRPCRequestSize.WithLabelValues(info.FullMethod).Observe(float64(proto.Size(msg)))
+		}
+
+		resp, err := handler(ctx, req)
+
+		code := metrics.GetStatusCodeFromError(err)
+		metrics.GRPCRequestDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+		metrics.GRPCRequestCount.WithLabelValues(info.FullMethod, code).Inc()
+		if err != nil {
+			metrics.GRPCRequestErrors.WithLabelValues(info.FullMethod, code).Inc()
+		}
+		return resp, err
+	}
+}
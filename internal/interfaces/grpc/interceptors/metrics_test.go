@@ -0,0 +1,34 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"testing"
+)
+
+func TestMetrics_RecordsStatusCodes(t *testing.T) {
+	interceptor := Metrics()
+	const method = "/test.Service/Metrics"
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	ok := func(context.Context, any) (any, error) { return "ok", nil }
+	denied := func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	_, err := interceptor(context.Background(), wrapperspb.String("payload"), info, ok)
+	require.NoError(t, err)
+	_, err = interceptor(context.Background(), wrapperspb.String("payload"), info, denied)
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.GRPCRequestCount.WithLabelValues(method, "OK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.GRPCRequestCount.WithLabelValues(method, "PermissionDenied")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(metrics.GRPCRequestErrors.WithLabelValues(method, "PermissionDenied")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.GRPCRequestErrors.WithLabelValues(method, "OK")))
+}
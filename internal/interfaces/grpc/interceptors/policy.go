@@ -0,0 +1,124 @@
+package interceptors
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	pb "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+)
+
+type UserLookup interface {
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+type SessionLookup interface {
+	GetSession(ctx context.Context, sessionID string) (*models.Session, error)
+}
+
+// DefaultPolicy is the access policy of every authenticated RPC.
+func DefaultPolicy(users UserLookup, sessions SessionLookup) Policy {
+	sessionOwner := func(ctx context.Context, sessionID string) (string, error) {
+		ses, err := sessions.GetSession(ctx, sessionID)
+		if err != nil {
+			return "", err
+		}
+		return ses.UserID, nil
+	}
+
+	return Policy{
+		"/auth.AuthService/Logout": {},
+		"/auth.AuthService/GetSession": {
+			Permissions: []string{models.PermSessionsRead},
+			Owner: func(ctx context.Context, req any) (string, error) {
+				return sessionOwner(ctx, req.(*pb.GetSessionRequest).GetSessionId())
+			},
+		},
+		"/auth.AuthService/ListSessions": {
+			Permissions: []string{models.PermSessionsRead},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.ListSessionsRequest).GetUserId(), nil
+			},
+		},
+		"/auth.AuthService/RevokeSession": {
+			Permissions: []string{models.PermSessionsRevoke},
+			Owner: func(ctx context.Context, req any) (string, error) {
+				return sessionOwner(ctx, req.(*pb.RevokeSessionRequest).GetSessionId())
+			},
+		},
+		"/auth.AuthService/RevokeAllSessions": {
+			Permissions: []string{models.PermSessionsRevoke},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.RevokeAllSessionsRequest).GetUserId(), nil
+			},
+		},
+		// the caller manages their own second factor only
+		"/auth.AuthService/EnrollTOTP":  {},
+		"/auth.AuthService/ConfirmTOTP": {},
+		"/auth.AuthService/DisableTOTP": {},
+		// passkeys are registered to the caller's own account
+		"/auth.AuthService/BeginPasskeyRegistration":  {},
+		"/auth.AuthService/FinishPasskeyRegistration": {},
+		"/auth.AuthService/ResendVerificationEmail": {
+			Permissions: []string{models.PermUsersUpdate},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.ResendVerificationEmailRequest).GetUserId(), nil
+			},
+		},
+
+		"/user.UserService/CreateUser": {
+			Permissions: []string{models.PermUsersCreate},
+		},
+		"/user.UserService/GetUserById": {
+			Permissions: []string{models.PermUsersRead},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.GetUserRequest).GetUserId(), nil
+			},
+		},
+		"/user.UserService/GetUserByEmail": {
+			Permissions: []string{models.PermUsersRead},
+			Owner: func(ctx context.Context, req any) (string, error) {
+				user, err := users.GetUserByEmail(ctx, req.(*pb.GetUserByEmailRequest).GetEmail())
+				if err != nil {
+					return "", err
+				}
+				return user.ID.String(), nil
+			},
+		},
+		"/user.UserService/UpdateUser": {
+			Permissions: []string{models.PermUsersUpdate},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.UpdateUserRequest).GetUserId(), nil
+			},
+		},
+		"/user.UserService/UpdateUserPassword": {
+			Permissions: []string{models.PermUsersUpdate},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.UpdateUserPasswordRequest).GetId(), nil
+			},
+		},
+		"/user.UserService/DeactivateUser": {
+			Permissions: []string{models.PermUsersDeactivate},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.DeactivateUserRequest).GetUserId(), nil
+			},
+		},
+		"/user.UserService/ReactivateUser": {
+			Permissions: []string{models.PermUsersDeactivate},
+		},
+		// not even for the owner, a lockout protects the account from them
+		"/user.UserService/UnlockUser": {
+			Permissions: []string{models.PermUsersDeactivate},
+		},
+		"/user.UserService/ValidatePassword": {
+			Permissions: []string{models.PermUsersValidatePassword},
+			Owner: func(_ context.Context, req any) (string, error) {
+				return req.(*pb.ValidatePasswordRequest).GetUserId(), nil
+			},
+		},
+		"/user.UserService/AssignRole": {
+			Permissions: []string{models.PermRolesManage},
+		},
+		"/user.UserService/RevokeRole": {
+			Permissions: []string{models.PermRolesManage},
+		},
+	}
+}
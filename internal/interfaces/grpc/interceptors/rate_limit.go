@@ -0,0 +1,79 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/authctx"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+type ClientResolver interface {
+	Resolve(ctx context.Context) models.ClientInfo
+}
+
+// RateLimit refuses calls over the limit of their method with
+// ResourceExhausted, telling when the next call is allowed. Calls are
+// counted per method and caller, as configured by the method's policy.
+// It comes before validation and authentication, so refused calls cost
+// neither, and leaves the policies counting per user to UserRateLimit.
+func RateLimit(limiter ratelimit.Limiter, conf *config.RateLimitConfig, clients ClientResolver) grpc.UnaryServerInterceptor {
+	return rateLimit(limiter, conf, clients, func(key string) bool { return key != "user" })
+}
+
+// UserRateLimit applies the policies counting per user, once Auth has
+// verified who the caller is.
+func UserRateLimit(limiter ratelimit.Limiter, conf *config.RateLimitConfig, clients ClientResolver) grpc.UnaryServerInterceptor {
+	return rateLimit(limiter, conf, clients, func(key string) bool { return key == "user" })
+}
+
+func rateLimit(limiter ratelimit.Limiter, conf *config.RateLimitConfig, clients ClientResolver, applies func(key string) bool) grpc.UnaryServerInterceptor {
+	apiKeys := make(map[string]bool, len(conf.APIKeys))
+	for _, digest := range conf.APIKeys {
+		apiKeys[strings.ToLower(digest)] = true
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		policy := conf.Policy(info.FullMethod)
+		if !conf.Enabled || policy.Requests <= 0 || !applies(policy.Key) {
+			return handler(ctx, req)
+		}
+
+		key := info.FullMethod + ":" + callerKey(ctx, policy.Key, conf.APIKeyHeader, apiKeys, clients)
+		allowed, retryAfter, err := limiter.Allow(ctx, key, policy.Requests, policy.Window)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, retryInfo(status.New(codes.ResourceExhausted, "rate limit exceeded"), retryAfter).Err()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// callerKey identifies the caller the way the policy asks for, falling back
+// to the IP for callers without a verified user or a known API key. API
+// keys are only ever handled as their digest.
+func callerKey(ctx context.Context, kind, apiKeyHeader string, apiKeys map[string]bool, clients ClientResolver) string {
+	switch kind {
+	case "user":
+		if p, ok := authctx.PrincipalFromContext(ctx); ok {
+			return "user:" + p.UserID
+		}
+	case "api_key":
+		md, _ := metadata.FromIncomingContext(ctx)
+		if keys := md.Get(apiKeyHeader); len(keys) > 0 && keys[0] != "" {
+			sum := sha256.Sum256([]byte(keys[0]))
+			if digest := hex.EncodeToString(sum[:]); apiKeys[digest] {
+				return "api_key:" + digest
+			}
+		}
+	}
+	return "ip:" + clients.Resolve(ctx).IpAddress
+}
@@ -0,0 +1,122 @@
+package interceptors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/authctx"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedClient string
+
+func (ip fixedClient) Resolve(context.Context) models.ClientInfo {
+	return models.ClientInfo{IpAddress: string(ip)}
+}
+
+func TestRateLimit(t *testing.T) {
+	conf := &config.RateLimitConfig{
+		Enabled:      true,
+		APIKeyHeader: "x-api-key",
+		APIKeys:      []string{digest("k1"), strings.ToUpper(digest("k2"))},
+		Default:      config.RateLimitPolicy{Requests: 2, Window: time.Minute, Key: "ip"},
+		Methods: map[string]config.RateLimitPolicy{
+			"/test.Service/PerUser":   {Requests: 1, Window: time.Minute, Key: "user"},
+			"/test.Service/PerAPIKey": {Requests: 1, Window: time.Minute, Key: "api_key"},
+			"/test.Service/Unlimited": {},
+		},
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	call := func(interceptor grpc.UnaryServerInterceptor, ctx context.Context, method string) error {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	asUser := func(uid string) context.Context {
+		return authctx.WithPrincipal(context.Background(), &models.Principal{UserID: uid})
+	}
+	withAPIKey := func(key string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", key))
+	}
+
+	t.Run("default policy per ip", func(t *testing.T) {
+		interceptor := RateLimit(ratelimit.NewMemory(), conf, fixedClient("10.0.0.1"))
+		ctx := context.Background()
+		require.NoError(t, call(interceptor, ctx, "/test.Service/Any"))
+		require.NoError(t, call(interceptor, ctx, "/test.Service/Any"))
+		err := call(interceptor, ctx, "/test.Service/Any")
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		var retryInfo *errdetails.RetryInfo
+		for _, detail := range status.Convert(err).Details() {
+			retryInfo, _ = detail.(*errdetails.RetryInfo)
+		}
+		require.NotNil(t, retryInfo)
+		assert.Positive(t, retryInfo.GetRetryDelay().AsDuration())
+		assert.LessOrEqual(t, retryInfo.GetRetryDelay().AsDuration(), time.Minute)
+
+		// methods are limited independently
+		assert.NoError(t, call(interceptor, ctx, "/test.Service/Other"))
+		other := RateLimit(ratelimit.NewMemory(), conf, fixedClient("10.0.0.2"))
+		assert.NoError(t, call(other, ctx, "/test.Service/Any"))
+	})
+
+	t.Run("per user", func(t *testing.T) {
+		interceptor := UserRateLimit(ratelimit.NewMemory(), conf, fixedClient("10.0.0.1"))
+		require.NoError(t, call(interceptor, asUser("u1"), "/test.Service/PerUser"))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(interceptor, asUser("u1"), "/test.Service/PerUser")))
+		assert.NoError(t, call(interceptor, asUser("u2"), "/test.Service/PerUser"))
+		// other policies are applied before authentication
+		for i := 0; i < 3; i++ {
+			require.NoError(t, call(interceptor, context.Background(), "/test.Service/Any"))
+		}
+	})
+
+	t.Run("per user policies wait for authentication", func(t *testing.T) {
+		interceptor := RateLimit(ratelimit.NewMemory(), conf, fixedClient("10.0.0.1"))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, call(interceptor, asUser("u1"), "/test.Service/PerUser"))
+		}
+	})
+
+	t.Run("per api key", func(t *testing.T) {
+		interceptor := RateLimit(ratelimit.NewMemory(), conf, fixedClient("10.0.0.1"))
+		require.NoError(t, call(interceptor, withAPIKey("k1"), "/test.Service/PerAPIKey"))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(interceptor, withAPIKey("k1"), "/test.Service/PerAPIKey")))
+		assert.NoError(t, call(interceptor, withAPIKey("k2"), "/test.Service/PerAPIKey"))
+		// without a key the ip is limited
+		require.NoError(t, call(interceptor, context.Background(), "/test.Service/PerAPIKey"))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(interceptor, context.Background(), "/test.Service/PerAPIKey")))
+	})
+
+	t.Run("unknown api keys count per ip", func(t *testing.T) {
+		interceptor := RateLimit(ratelimit.NewMemory(), conf, fixedClient("10.0.0.1"))
+		require.NoError(t, call(interceptor, withAPIKey("made-up-1"), "/test.Service/PerAPIKey"))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(interceptor, withAPIKey("made-up-2"), "/test.Service/PerAPIKey")))
+		assert.NoError(t, call(interceptor, withAPIKey("k1"), "/test.Service/PerAPIKey"))
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		interceptor := RateLimit(ratelimit.NewMemory(), conf, fixedClient("10.0.0.1"))
+		for i := 0; i < 5; i++ {
+			require.NoError(t, call(interceptor, context.Background(), "/test.Service/Unlimited"))
+		}
+	})
+}
+
+func digest(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -35,10 +35,56 @@ func Validation() grpc.UnaryServerInterceptor {
 			validationErr = validateUpdateUserPassReq(r)
 		case *pb.GetUserRequest:
 			validationErr = validateGetUserReq(r)
+		case *pb.UpdateUserRequest:
+			validationErr = validateUpdateUserReq(r)
+		case *pb.RequestPasswordResetRequest:
+			validationErr = validateRequestPasswordResetReq(r)
+		case *pb.ResetPasswordRequest:
+			validationErr = validateResetPasswordReq(r)
+		case *pb.ConfirmTOTPRequest:
+			validationErr = validateMFACodeReq(r.GetCode())
+		case *pb.DisableTOTPRequest:
+			validationErr = validateMFACodeReq(r.GetCode())
+		case *pb.VerifyMFARequest:
+			validationErr = validateVerifyMFAReq(r)
+		case *pb.FinishPasskeyRegistrationRequest:
+			validationErr = validatePasskeyCredentialReq(r.GetCeremonyId(), r.GetCredential(), "", "")
+		case *pb.FinishPasskeyLoginRequest:
+			validationErr = validatePasskeyCredentialReq(r.GetCeremonyId(), r.GetCredential(), r.GetDeviceId(), r.GetDeviceName())
+		case *pb.StartPasswordlessLoginRequest:
+			validationErr = validateStartPasswordlessLoginReq(r)
+		case *pb.CompletePasswordlessLoginRequest:
+			validationErr = validateCompletePasswordlessLoginReq(r)
+		case *pb.VerifyEmailRequest:
+			validationErr = validateActionTokenReq(r.GetToken())
+		case *pb.ResendVerificationEmailRequest:
+			validationErr = validateUserIDReq(r.GetUserId())
+		case *pb.ConfirmEmailChangeRequest:
+			validationErr = validateActionTokenReq(r.GetToken())
+		case *pb.DeactivateUserRequest:
+			validationErr = validateDeactivateUserReq(r)
+		case *pb.ReactivateUserRequest:
+			validationErr = validateUserIDReq(r.GetUserId())
+		case *pb.UnlockUserRequest:
+			validationErr = validateUserIDReq(r.GetUserId())
+		case *pb.ValidatePasswordRequest:
+			validationErr = validateValidatePasswordReq(r)
+		case *pb.GetSessionRequest:
+			validationErr = validateSessionReq(r.GetSessionId())
+		case *pb.RevokeSessionRequest:
+			validationErr = validateSessionReq(r.GetSessionId())
+		case *pb.ListSessionsRequest:
+			validationErr = validateListSessionsReq(r)
+		case *pb.RevokeAllSessionsRequest:
+			validationErr = validateRevokeAllSessionsReq(r)
+		case *pb.AssignRoleRequest:
+			validationErr = validateRoleReq(r.GetUserId(), r.GetRole())
+		case *pb.RevokeRoleRequest:
+			validationErr = validateRoleReq(r.GetUserId(), r.GetRole())
 		}
 
 		if validationErr != nil {
-			return nil, status.Errorf(
+			return nil, status.Error(
 				codes.InvalidArgument,
 				formatValidationError(validationErr))
 		}
@@ -74,11 +120,122 @@ func validateGetUserReq(req *pb.GetUserRequest) error {
 	return validation.ValidateStruct(&validationReq)
 }
 
+func validateUpdateUserReq(req *pb.UpdateUserRequest) error {
+	validationReq := validation.UpdateUserEmailRequest{
+		ID:    req.GetUserId(),
+		Email: req.GetEmail(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateRequestPasswordResetReq(req *pb.RequestPasswordResetRequest) error {
+	validationReq := validation.RequestPasswordResetRequest{
+		Email: req.GetEmail(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateResetPasswordReq(req *pb.ResetPasswordRequest) error {
+	validationReq := validation.ResetPasswordRequest{
+		Token:              req.GetToken(),
+		NewPassword:        req.GetNewPassword(),
+		NewPasswordConfirm: req.GetNewPasswordConfirm(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateMFACodeReq(code string) error {
+	validationReq := validation.MFACodeRequest{
+		Code: code,
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateVerifyMFAReq(req *pb.VerifyMFARequest) error {
+	validationReq := validation.VerifyMFARequest{
+		MFAToken: req.GetMfaToken(),
+		Code:     req.GetCode(),
+		DeviceRequest: validation.DeviceRequest{
+			DeviceID:   req.GetDeviceId(),
+			DeviceName: req.GetDeviceName(),
+		},
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validatePasskeyCredentialReq(ceremonyID, credential, deviceID, deviceName string) error {
+	validationReq := validation.PasskeyCredentialRequest{
+		CeremonyID: ceremonyID,
+		Credential: credential,
+		DeviceRequest: validation.DeviceRequest{
+			DeviceID:   deviceID,
+			DeviceName: deviceName,
+		},
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateStartPasswordlessLoginReq(req *pb.StartPasswordlessLoginRequest) error {
+	validationReq := validation.StartPasswordlessLoginRequest{
+		Email: req.GetEmail(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateCompletePasswordlessLoginReq(req *pb.CompletePasswordlessLoginRequest) error {
+	validationReq := validation.CompletePasswordlessLoginRequest{
+		Token: req.GetToken(),
+		Email: req.GetEmail(),
+		Code:  req.GetCode(),
+		DeviceRequest: validation.DeviceRequest{
+			DeviceID:   req.GetDeviceId(),
+			DeviceName: req.GetDeviceName(),
+		},
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateActionTokenReq(token string) error {
+	validationReq := validation.ActionTokenRequest{
+		Token: token,
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateDeactivateUserReq(req *pb.DeactivateUserRequest) error {
+	validationReq := validation.DeactivateUserRequest{
+		UserID: req.GetUserId(),
+		Reason: req.GetReason(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateUserIDReq(userID string) error {
+	validationReq := validation.GetUserRequest{
+		ID: userID,
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateValidatePasswordReq(req *pb.ValidatePasswordRequest) error {
+	validationReq := validation.ValidatePasswordRequest{
+		UserID:   req.GetUserId(),
+		Password: req.GetPassword(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
 func validateRegisterReq(req *pb.RegisterRequest) error {
-	validationReq := validation.CreateUserRequest{
-		Email:           req.GetEmail(),
-		Password:        req.GetPassword(),
-		PasswordConfirm: req.GetPasswordConfirm(),
+	validationReq := validation.RegisterRequest{
+		CreateUserRequest: validation.CreateUserRequest{
+			Email:           req.GetEmail(),
+			Password:        req.GetPassword(),
+			PasswordConfirm: req.GetPasswordConfirm(),
+		},
+		DeviceRequest: validation.DeviceRequest{
+			DeviceID:   req.GetDeviceId(),
+			DeviceName: req.GetDeviceName(),
+		},
 	}
 	return validation.ValidateStruct(&validationReq)
 }
@@ -87,6 +244,41 @@ func validateLoginReq(req *pb.LoginRequest) error {
 	validationReq := validation.LoginRequest{
 		Email:    req.GetEmail(),
 		Password: req.GetPassword(),
+		DeviceRequest: validation.DeviceRequest{
+			DeviceID:   req.GetDeviceId(),
+			DeviceName: req.GetDeviceName(),
+		},
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateSessionReq(sessionID string) error {
+	validationReq := validation.SessionRequest{
+		SessionID: sessionID,
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateListSessionsReq(req *pb.ListSessionsRequest) error {
+	validationReq := validation.ListSessionsRequest{
+		UserID:   req.GetUserId(),
+		PageSize: req.GetPageSize(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateRevokeAllSessionsReq(req *pb.RevokeAllSessionsRequest) error {
+	validationReq := validation.RevokeAllSessionsRequest{
+		UserID:          req.GetUserId(),
+		ExceptSessionID: req.GetExceptSessionId(),
+	}
+	return validation.ValidateStruct(&validationReq)
+}
+
+func validateRoleReq(userID, role string) error {
+	validationReq := validation.RoleRequest{
+		UserID: userID,
+		Role:   role,
 	}
 	return validation.ValidateStruct(&validationReq)
 }
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/services"
+)
+
+type Container struct {
+	JWKS    *JWKSHandler
+	Cookies *SessionCookies
+}
+
+func NewContainer(
+	services *services.Container,
+	cfg *config.Config,
+	logger *logger.Logger,
+) *Container {
+	return &Container{
+		JWKS:    NewJWKSHandler(services.AuthService, logger),
+		Cookies: NewSessionCookies(cfg.Cookies, cfg.JWTConfig),
+	}
+}
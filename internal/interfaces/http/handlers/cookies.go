@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	pb "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// sessionModeHeader opts a request into the cookie mode.
+	sessionModeHeader = "X-Session-Mode"
+	sessionModeCookie = "cookie"
+	// csrfHeader must repeat the CSRF cookie on requests authenticated by
+	// the session cookies.
+	csrfHeader = "X-CSRF-Token"
+)
+
+var errInvalidCSRF = status.Error(codes.PermissionDenied, "invalid csrf token")
+
+// SessionCookies keeps the tokens of browser clients in cookies. Session
+// cookies are HttpOnly, next to them a readable CSRF cookie is set which the
+// client has to send back in the X-CSRF-Token header whenever the session
+// cookies authenticate a request (double-submit). Another site can make the
+// browser send the cookies but can't read the token.
+type SessionCookies struct {
+	conf       *config.SessionCookieConfig
+	sameSite   http.SameSite
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewSessionCookies(conf *config.SessionCookieConfig, tokens *config.JWTConfig) *SessionCookies {
+	sameSite, err := parseSameSite(conf.SameSite)
+	if err != nil {
+		panic(err)
+	}
+	if conf.Enabled && sameSite == http.SameSiteNoneMode && !conf.Secure {
+		panic("session cookies: same_site none requires secure")
+	}
+	return &SessionCookies{
+		conf:       conf,
+		sameSite:   sameSite,
+		accessTTL:  tokens.AccessTokenTTL,
+		refreshTTL: tokens.RefreshTokenTTL,
+	}
+}
+
+// Requested reports whether the client asked for the cookie mode.
+func (c *SessionCookies) Requested(r *http.Request) bool {
+	return c.conf.Enabled && strings.EqualFold(r.Header.Get(sessionModeHeader), sessionModeCookie)
+}
+
+// Authorize authenticates a request without an authorization header by
+// the access token cookie. It reports whether the cookie was used.
+func (c *SessionCookies) Authorize(r *http.Request, md metadata.MD) (bool, error) {
+	if !c.conf.Enabled || !c.conf.AccessToken || len(md.Get("authorization")) > 0 {
+		return false, nil
+	}
+	cookie, err := r.Cookie(c.conf.AccessName)
+	if err != nil || cookie.Value == "" {
+		return false, nil
+	}
+	if err = c.checkCSRF(r); err != nil {
+		return false, err
+	}
+	md.Set("authorization", "Bearer "+cookie.Value)
+	return true, nil
+}
+
+// Bind fills the refresh token of a RefreshToken call from the cookie when
+// the body has none. It reports whether the cookie was used.
+func (c *SessionCookies) Bind(r *http.Request, req any) (bool, error) {
+	refresh, ok := req.(*pb.RefreshTokenRequest)
+	if !c.conf.Enabled || !ok || refresh.GetRefreshToken() != "" {
+		return false, nil
+	}
+	cookie, err := r.Cookie(c.conf.RefreshName)
+	if err != nil || cookie.Value == "" {
+		return false, nil
+	}
+	if err = c.checkCSRF(r); err != nil {
+		return false, err
+	}
+	refresh.RefreshToken = cookie.Value
+	return true, nil
+}
+
+// Issue moves the tokens of a session response into cookies and sets a new
+// CSRF token. Responses without tokens, e.g. asking for a second factor,
+// are left alone.
+func (c *SessionCookies) Issue(w http.ResponseWriter, resp any) {
+	ses, ok := resp.(*pb.SessionResponse)
+	if !ok || ses.GetRefreshToken() == "" {
+		return
+	}
+	csrf := rand.Text()
+	http.SetCookie(w, c.cookie(c.conf.RefreshName, ses.GetRefreshToken(), c.refreshTTL, true))
+	http.SetCookie(w, c.cookie(c.conf.CSRFName, csrf, c.refreshTTL, false))
+	ses.RefreshToken = ""
+	if c.conf.AccessToken {
+		http.SetCookie(w, c.cookie(c.conf.AccessName, ses.GetAccessToken(), c.accessTTL, true))
+		ses.AccessToken = ""
+	}
+}
+
+// Clear removes the cookies of the session.
+func (c *SessionCookies) Clear(w http.ResponseWriter) {
+	if !c.conf.Enabled {
+		return
+	}
+	for _, name := range []string{c.conf.RefreshName, c.conf.AccessName, c.conf.CSRFName} {
+		cookie := c.cookie(name, "", 0, name != c.conf.CSRFName)
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+func (c *SessionCookies) checkCSRF(r *http.Request) error {
+	cookie, err := r.Cookie(c.conf.CSRFName)
+	if err != nil || cookie.Value == "" {
+		return errInvalidCSRF
+	}
+	header := r.Header.Get(csrfHeader)
+	if subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 {
+		return errInvalidCSRF
+	}
+	return nil
+}
+
+func (c *SessionCookies) cookie(name, value string, ttl time.Duration, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     c.conf.Path,
+		Domain:   c.conf.Domain,
+		MaxAge:   int(ttl.Seconds()),
+		Secure:   c.conf.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.sameSite,
+	}
+}
+
+func parseSameSite(mode string) (http.SameSite, error) {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("session cookies: unknown same_site %q", mode)
+	}
+}
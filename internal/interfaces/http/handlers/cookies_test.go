@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Roflan4eg/auth-serivce/config"
+	pb "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type cookieAuthServer struct {
+	pb.UnimplementedAuthServiceServer
+	refreshed string
+	loggedOut string
+}
+
+func (s *cookieAuthServer) Login(context.Context, *pb.LoginRequest) (*pb.SessionResponse, error) {
+	return &pb.SessionResponse{AccessToken: "access-1", RefreshToken: "refresh-1"}, nil
+}
+
+func (s *cookieAuthServer) RefreshToken(_ context.Context, req *pb.RefreshTokenRequest) (*pb.SessionResponse, error) {
+	s.refreshed = req.GetRefreshToken()
+	return &pb.SessionResponse{AccessToken: "access-2", RefreshToken: "refresh-2"}, nil
+}
+
+func (s *cookieAuthServer) Logout(ctx context.Context, _ *pb.LogoutRequest) (*emptypb.Empty, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		s.loggedOut = values[0]
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func cookieConfig(accessToken bool) *config.SessionCookieConfig {
+	return &config.SessionCookieConfig{
+		Enabled:     true,
+		AccessToken: accessToken,
+		Path:        "/",
+		Secure:      true,
+		SameSite:    "strict",
+		RefreshName: "refresh_token",
+		AccessName:  "access_token",
+		CSRFName:    "csrf_token",
+	}
+}
+
+func serveRequest(mux *http.ServeMux, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func responseCookies(rec *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range rec.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+func TestSessionCookies_LoginInCookieMode(t *testing.T) {
+	mux := newCookieGateway(t, &cookieAuthServer{}, &fakeUserServer{}, passThrough, cookieConfig(false))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{}`))
+	req.Header.Set("X-Session-Mode", "cookie")
+	rec := serveRequest(mux, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := responseCookies(rec)
+	require.Contains(t, cookies, "refresh_token")
+	assert.Equal(t, "refresh-1", cookies["refresh_token"].Value)
+	assert.True(t, cookies["refresh_token"].HttpOnly)
+	assert.True(t, cookies["refresh_token"].Secure)
+	assert.Equal(t, http.SameSiteStrictMode, cookies["refresh_token"].SameSite)
+	require.Contains(t, cookies, "csrf_token")
+	assert.NotEmpty(t, cookies["csrf_token"].Value)
+	assert.False(t, cookies["csrf_token"].HttpOnly)
+	assert.NotContains(t, cookies, "access_token")
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "access-1", body["access_token"])
+	assert.NotContains(t, body, "refresh_token")
+}
+
+func TestSessionCookies_BodyFlowUnchanged(t *testing.T) {
+	mux := newCookieGateway(t, &cookieAuthServer{}, &fakeUserServer{}, passThrough, cookieConfig(true))
+
+	rec := serveRequest(mux, httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{}`)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "access-1", body["access_token"])
+	assert.Equal(t, "refresh-1", body["refresh_token"])
+}
+
+func TestSessionCookies_RefreshRequiresCSRF(t *testing.T) {
+	auth := &cookieAuthServer{}
+	mux := newCookieGateway(t, auth, &fakeUserServer{}, passThrough, cookieConfig(false))
+
+	refresh := func(csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/refresh", strings.NewReader(`{}`))
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh-1"})
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "csrf-1"})
+		if csrf != "" {
+			req.Header.Set("X-CSRF-Token", csrf)
+		}
+		return serveRequest(mux, req)
+	}
+
+	assert.Equal(t, http.StatusForbidden, refresh("").Code)
+	assert.Equal(t, http.StatusForbidden, refresh("csrf-2").Code)
+	assert.Empty(t, auth.refreshed)
+
+	rec := refresh("csrf-1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "refresh-1", auth.refreshed)
+	cookies := responseCookies(rec)
+	require.Contains(t, cookies, "refresh_token")
+	assert.Equal(t, "refresh-2", cookies["refresh_token"].Value)
+	require.Contains(t, cookies, "csrf_token")
+	assert.NotEqual(t, "csrf-1", cookies["csrf_token"].Value)
+}
+
+func TestSessionCookies_AccessCookieAuthenticatesAndLogoutClears(t *testing.T) {
+	auth := &cookieAuthServer{}
+	mux := newCookieGateway(t, auth, &fakeUserServer{}, passThrough, cookieConfig(true))
+
+	logout := func(csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/logout", strings.NewReader(`{}`))
+		req.AddCookie(&http.Cookie{Name: "access_token", Value: "access-1"})
+		req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "csrf-1"})
+		req.Header.Set("X-CSRF-Token", csrf)
+		return serveRequest(mux, req)
+	}
+
+	assert.Equal(t, http.StatusForbidden, logout("wrong").Code)
+	assert.Empty(t, auth.loggedOut)
+
+	rec := logout("csrf-1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Bearer access-1", auth.loggedOut)
+	cookies := responseCookies(rec)
+	for _, name := range []string{"refresh_token", "access_token", "csrf_token"} {
+		require.Contains(t, cookies, name)
+		assert.Equal(t, -1, cookies[name].MaxAge)
+	}
+}
+
+func TestNewSessionCookies_RejectsInsecureSameSiteNone(t *testing.T) {
+	conf := cookieConfig(false)
+	conf.SameSite, conf.Secure = "none", false
+	assert.Panics(t, func() { NewSessionCookies(conf, &config.JWTConfig{}) })
+
+	conf.SameSite = "sometimes"
+	assert.Panics(t, func() { NewSessionCookies(conf, &config.JWTConfig{}) })
+}
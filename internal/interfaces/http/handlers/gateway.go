@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pb "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBodySize bounds request bodies, the largest requests carry a passkey
+// credential.
+const maxBodySize = 1 << 20
+
+// gatewayRoute maps an HTTP pattern of http.ServeMux to an RPC. Wildcards of
+// the pattern, the query and the JSON body fill the fields of the request
+// message with the same name.
+type gatewayRoute struct {
+	pattern string
+	rpc     string
+}
+
+var authRoutes = []gatewayRoute{
+	{"POST /v1/auth/register", "Register"},
+	{"POST /v1/auth/login", "Login"},
+	{"POST /v1/auth/logout", "Logout"},
+	{"POST /v1/auth/refresh", "RefreshToken"},
+	{"POST /v1/auth/validate", "ValidateToken"},
+	{"GET /v1/sessions/{session_id}", "GetSession"},
+	{"DELETE /v1/sessions/{session_id}", "RevokeSession"},
+	{"GET /v1/users/{user_id}/sessions", "ListSessions"},
+	{"DELETE /v1/users/{user_id}/sessions", "RevokeAllSessions"},
+	{"POST /v1/auth/verify-email", "VerifyEmail"},
+	{"POST /v1/users/{user_id}/verification-email", "ResendVerificationEmail"},
+	{"POST /v1/auth/password-reset", "RequestPasswordReset"},
+	{"POST /v1/auth/password-reset/confirm", "ResetPassword"},
+	{"POST /v1/auth/mfa/totp", "EnrollTOTP"},
+	{"POST /v1/auth/mfa/totp/confirm", "ConfirmTOTP"},
+	{"POST /v1/auth/mfa/totp/disable", "DisableTOTP"},
+	{"POST /v1/auth/mfa/verify", "VerifyMFA"},
+	{"POST /v1/auth/passkeys/registration", "BeginPasskeyRegistration"},
+	{"POST /v1/auth/passkeys/registration/finish", "FinishPasskeyRegistration"},
+	{"POST /v1/auth/passkeys/login", "BeginPasskeyLogin"},
+	{"POST /v1/auth/passkeys/login/finish", "FinishPasskeyLogin"},
+	{"POST /v1/auth/passwordless", "StartPasswordlessLogin"},
+	{"POST /v1/auth/passwordless/complete", "CompletePasswordlessLogin"},
+}
+
+var userRoutes = []gatewayRoute{
+	{"POST /v1/users", "CreateUser"},
+	{"GET /v1/users/{user_id}", "GetUserById"},
+	{"GET /v1/users", "GetUserByEmail"},
+	{"PATCH /v1/users/{user_id}", "UpdateUser"},
+	{"POST /v1/users/email-change/confirm", "ConfirmEmailChange"},
+	{"PUT /v1/users/{id}/password", "UpdateUserPassword"},
+	{"POST /v1/users/{user_id}/password/validate", "ValidatePassword"},
+	{"POST /v1/users/{user_id}/deactivate", "DeactivateUser"},
+	{"POST /v1/users/{user_id}/reactivate", "ReactivateUser"},
+	{"POST /v1/users/{user_id}/unlock", "UnlockUser"},
+	{"PUT /v1/users/{user_id}/roles/{role}", "AssignRole"},
+	{"DELETE /v1/users/{user_id}/roles/{role}", "RevokeRole"},
+}
+
+var wildcardPattern = regexp.MustCompile(`\{(\w+)}`)
+
+var (
+	jsonIn  = protojson.UnmarshalOptions{}
+	jsonOut = protojson.MarshalOptions{UseProtoNames: true}
+)
+
+// GatewayHandler serves the gRPC services as JSON over HTTP. Calls go
+// through the same interceptors as over gRPC, so validation, logging,
+// authentication and authorization behave alike, and gRPC status codes
+// are answered with the matching HTTP status. Browser clients can keep
+// their tokens in cookies, see SessionCookies.
+type GatewayHandler struct {
+	services    []gatewayService
+	interceptor grpc.UnaryServerInterceptor
+	cookies     *SessionCookies
+	timeout     time.Duration
+	log         *logger.Logger
+}
+
+type gatewayService struct {
+	desc   *grpc.ServiceDesc
+	impl   any
+	routes []gatewayRoute
+}
+
+func NewGatewayHandler(
+	auth pb.AuthServiceServer,
+	users pb.UserServiceServer,
+	interceptor grpc.UnaryServerInterceptor,
+	cookies *SessionCookies,
+	timeout time.Duration,
+	log *logger.Logger,
+) *GatewayHandler {
+	return &GatewayHandler{
+		services: []gatewayService{
+			{desc: &pb.AuthService_ServiceDesc, impl: auth, routes: authRoutes},
+			{desc: &pb.UserService_ServiceDesc, impl: users, routes: userRoutes},
+		},
+		interceptor: interceptor,
+		cookies:     cookies,
+		timeout:     timeout,
+		log:         log,
+	}
+}
+
+func (h *GatewayHandler) RegisterHandler(mux *http.ServeMux) {
+	for _, svc := range h.services {
+		methods := make(map[string]grpc.MethodDesc, len(svc.desc.Methods))
+		for _, m := range svc.desc.Methods {
+			methods[m.MethodName] = m
+		}
+		for _, route := range svc.routes {
+			method, ok := methods[route.rpc]
+			if !ok {
+				panic(fmt.Sprintf("gateway: %s has no method %s", svc.desc.ServiceName, route.rpc))
+			}
+			mux.Handle(route.pattern, h.handle(svc.impl, method, route))
+		}
+	}
+}
+
+func (h *GatewayHandler) handle(impl any, method grpc.MethodDesc, route gatewayRoute) http.Handler {
+	var wildcards []string
+	for _, m := range wildcardPattern.FindAllStringSubmatch(route.pattern, -1) {
+		wildcards = append(wildcards, m[1])
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := incomingMetadata(r)
+		cookieAuth, err := h.cookies.Authorize(r, md)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(incomingContext(r, md), h.timeout)
+		defer cancel()
+
+		decode := func(in any) error {
+			if err := bindRequest(r, wildcards, in.(proto.Message)); err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			used, err := h.cookies.Bind(r, in)
+			cookieAuth = cookieAuth || used
+			return err
+		}
+		resp, err := method.Handler(impl, ctx, decode, h.interceptor)
+		if route.rpc == "Logout" {
+			// the client is logged out either way
+			h.cookies.Clear(w)
+		}
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		if cookieAuth || h.cookies.Requested(r) {
+			h.cookies.Issue(w, resp)
+		}
+		body, err := jsonOut.Marshal(resp.(proto.Message))
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		h.write(w, r, http.StatusOK, body)
+	})
+}
+
+func incomingMetadata(r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	for name, values := range r.Header {
+		md.Append(strings.ToLower(name), values...)
+	}
+	return md
+}
+
+// incomingContext presents the request the way the interceptors expect a
+// gRPC call: headers as metadata and the remote address as the peer.
+func incomingContext(r *http.Request, md metadata.MD) context.Context {
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	return ctx
+}
+
+// bindRequest fills msg from the JSON body, then the query and then the
+// wildcards of the path, later sources overriding earlier ones.
+func bindRequest(r *http.Request, wildcards []string, msg proto.Message) error {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	if len(body) > 0 {
+		if err = jsonIn.Unmarshal(body, msg); err != nil {
+			return fmt.Errorf("invalid body: %w", err)
+		}
+	}
+	for name, values := range r.URL.Query() {
+		if err = setField(msg.ProtoReflect(), name, values[len(values)-1]); err != nil {
+			return err
+		}
+	}
+	for _, name := range wildcards {
+		if err = setField(msg.ProtoReflect(), name, r.PathValue(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setField(msg protoreflect.Message, name, value string) error {
+	fields := msg.Descriptor().Fields()
+	fd := fields.ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = fields.ByJSONName(name)
+	}
+	if fd == nil || fd.IsList() || fd.IsMap() {
+		return fmt.Errorf("unknown parameter %q", name)
+	}
+
+	var (
+		v   protoreflect.Value
+		err error
+	)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(value)
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(value)
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var n int64
+		n, err = strconv.ParseInt(value, 10, 32)
+		v = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(value)); ev != nil {
+			v = protoreflect.ValueOfEnum(ev.Number())
+			break
+		}
+		var n int64
+		n, err = strconv.ParseInt(value, 10, 32)
+		v = protoreflect.ValueOfEnum(protoreflect.EnumNumber(n))
+	default:
+		return fmt.Errorf("parameter %q can't be set from the URL", name)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid parameter %q: %w", name, err)
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+// writeError answers with the HTTP status matching the gRPC code and the
+// google.rpc.Status as body. Refusals telling when to retry set
+// Retry-After.
+func (h *GatewayHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	st := status.Convert(err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		st = status.New(codes.DeadlineExceeded, err.Error())
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := math.Ceil(info.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+		}
+	}
+	body, err := jsonOut.Marshal(st.Proto())
+	if err != nil {
+		h.log.ErrorContext(r.Context(), "failed to encode error", h.log.String("error", err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.write(w, r, httpStatus(st.Code()), body)
+}
+
+func (h *GatewayHandler) write(w http.ResponseWriter, r *http.Request, code int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if _, err := w.Write(body); err != nil {
+		h.log.WarnContext(r.Context(), "failed to write response", h.log.String("error", err.Error()))
+	}
+}
+
+// httpStatus maps gRPC codes like google.rpc.Code documents it.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
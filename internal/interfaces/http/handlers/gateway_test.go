@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Roflan4eg/auth-serivce/config"
+	pb "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	l "github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeAuthServer struct {
+	pb.UnimplementedAuthServiceServer
+	login      func(ctx context.Context, req *pb.LoginRequest) (*pb.SessionResponse, error)
+	getSession func(ctx context.Context, req *pb.GetSessionRequest) (*pb.SessionInfo, error)
+}
+
+func (s *fakeAuthServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.SessionResponse, error) {
+	return s.login(ctx, req)
+}
+
+func (s *fakeAuthServer) GetSession(ctx context.Context, req *pb.GetSessionRequest) (*pb.SessionInfo, error) {
+	return s.getSession(ctx, req)
+}
+
+type fakeUserServer struct {
+	pb.UnimplementedUserServiceServer
+	getUserByEmail func(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.UserResponse, error)
+}
+
+func (s *fakeUserServer) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.UserResponse, error) {
+	return s.getUserByEmail(ctx, req)
+}
+
+func passThrough(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(ctx, req)
+}
+
+func newGateway(t *testing.T, auth pb.AuthServiceServer, users pb.UserServiceServer, interceptor grpc.UnaryServerInterceptor) *http.ServeMux {
+	t.Helper()
+	return newCookieGateway(t, auth, users, interceptor, &config.SessionCookieConfig{SameSite: "strict"})
+}
+
+func newCookieGateway(
+	t *testing.T,
+	auth pb.AuthServiceServer,
+	users pb.UserServiceServer,
+	interceptor grpc.UnaryServerInterceptor,
+	conf *config.SessionCookieConfig,
+) *http.ServeMux {
+	t.Helper()
+	logger := &l.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	cookies := NewSessionCookies(conf, &config.JWTConfig{AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: time.Hour})
+	mux := http.NewServeMux()
+	NewGatewayHandler(auth, users, interceptor, cookies, time.Second, logger).RegisterHandler(mux)
+	return mux
+}
+
+func serve(mux *http.ServeMux, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.RemoteAddr = "203.0.113.7:51234"
+	req.Header.Set("User-Agent", "gateway-test")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGateway_EveryRPCHasARoute(t *testing.T) {
+	for _, svc := range []struct {
+		desc   *grpc.ServiceDesc
+		routes []gatewayRoute
+	}{
+		{&pb.AuthService_ServiceDesc, authRoutes},
+		{&pb.UserService_ServiceDesc, userRoutes},
+	} {
+		routed := make(map[string]bool)
+		for _, route := range svc.routes {
+			routed[route.rpc] = true
+		}
+		for _, m := range svc.desc.Methods {
+			assert.True(t, routed[m.MethodName], "%s/%s has no route", svc.desc.ServiceName, m.MethodName)
+		}
+	}
+}
+
+func TestGateway_BindsBodyAndRunsInterceptor(t *testing.T) {
+	var method string
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method = info.FullMethod
+		return handler(ctx, req)
+	}
+	auth := &fakeAuthServer{login: func(ctx context.Context, req *pb.LoginRequest) (*pb.SessionResponse, error) {
+		assert.Equal(t, "user@example.com", req.GetEmail())
+		assert.Equal(t, "secret", req.GetPassword())
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		assert.Equal(t, []string{"gateway-test"}, md.Get("user-agent"))
+		p, ok := peer.FromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "203.0.113.7:51234", p.Addr.String())
+		return &pb.SessionResponse{AccessToken: "access"}, nil
+	}}
+	mux := newGateway(t, auth, &fakeUserServer{}, interceptor)
+
+	rec := serve(mux, http.MethodPost, "/v1/auth/login", `{"email":"user@example.com","password":"secret"}`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, pb.AuthService_Login_FullMethodName, method)
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "access", body["access_token"])
+}
+
+func TestGateway_BindsPathAndQuery(t *testing.T) {
+	auth := &fakeAuthServer{getSession: func(_ context.Context, req *pb.GetSessionRequest) (*pb.SessionInfo, error) {
+		assert.Equal(t, "ses-1", req.GetSessionId())
+		return &pb.SessionInfo{}, nil
+	}}
+	users := &fakeUserServer{getUserByEmail: func(_ context.Context, req *pb.GetUserByEmailRequest) (*pb.UserResponse, error) {
+		assert.Equal(t, "user@example.com", req.GetEmail())
+		return &pb.UserResponse{}, nil
+	}}
+	mux := newGateway(t, auth, users, passThrough)
+
+	assert.Equal(t, http.StatusOK, serve(mux, http.MethodGet, "/v1/sessions/ses-1", "").Code)
+	assert.Equal(t, http.StatusOK, serve(mux, http.MethodGet, "/v1/users?email=user@example.com", "").Code)
+}
+
+func TestGateway_RejectsMalformedRequests(t *testing.T) {
+	auth := &fakeAuthServer{login: func(context.Context, *pb.LoginRequest) (*pb.SessionResponse, error) {
+		t.Fatal("handler called")
+		return nil, nil
+	}}
+	mux := newGateway(t, auth, &fakeUserServer{}, passThrough)
+
+	assert.Equal(t, http.StatusBadRequest, serve(mux, http.MethodPost, "/v1/auth/login", `{"email":`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(mux, http.MethodPost, "/v1/auth/login", `{"unknown":"x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve(mux, http.MethodPost, "/v1/auth/login?unknown=x", "").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(mux, http.MethodGet, "/v1/auth/login", "").Code)
+}
+
+func TestGateway_TranslatesErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		retryAfter string
+	}{
+		{"invalid argument", status.Error(codes.InvalidArgument, "bad email"), http.StatusBadRequest, ""},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "invalid credentials"), http.StatusUnauthorized, ""},
+		{"permission denied", status.Error(codes.PermissionDenied, "forbidden"), http.StatusForbidden, ""},
+		{"not found", status.Error(codes.NotFound, "not found"), http.StatusNotFound, ""},
+		{"already exists", status.Error(codes.AlreadyExists, "exists"), http.StatusConflict, ""},
+		{"internal", status.Error(codes.Internal, "internal"), http.StatusInternalServerError, ""},
+		{"exhausted", withRetry(codes.ResourceExhausted, 1500*time.Millisecond), http.StatusTooManyRequests, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &fakeAuthServer{login: func(context.Context, *pb.LoginRequest) (*pb.SessionResponse, error) {
+				return nil, tt.err
+			}}
+			mux := newGateway(t, auth, &fakeUserServer{}, passThrough)
+
+			rec := serve(mux, http.MethodPost, "/v1/auth/login", `{}`)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.retryAfter, rec.Header().Get("Retry-After"))
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, status.Convert(tt.err).Message(), body["message"])
+		})
+	}
+}
+
+func withRetry(code codes.Code, d time.Duration) error {
+	st, _ := status.New(code, "slow down").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(d)})
+	return st.Err()
+}
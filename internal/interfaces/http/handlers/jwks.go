@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/services"
+	"net/http"
+)
+
+type JWKSHandler struct {
+	authService *services.AuthService
+	log         *logger.Logger
+}
+
+func NewJWKSHandler(authService *services.AuthService, log *logger.Logger) *JWKSHandler {
+	return &JWKSHandler{authService: authService, log: log}
+}
+
+func (h *JWKSHandler) RegisterHandler(mux *http.ServeMux) {
+	mux.HandleFunc("GET /.well-known/jwks.json", h.ServeJWKS)
+}
+
+func (h *JWKSHandler) ServeJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// keep caches short so consumers pick up a rotated key quickly
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(h.authService.JWKS()); err != nil {
+		h.log.ErrorContext(r.Context(), "failed to write jwks", h.log.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"time"
+)
+
+// LogSink writes security events to the service log under the "audit" group,
+// so they can be shipped and alerted on together with the rest of the logs.
+type LogSink struct {
+	log *logger.Logger
+}
+
+func NewLogSink(log *logger.Logger) *LogSink {
+	return &LogSink{log: log}
+}
+
+func (s *LogSink) Emit(ctx context.Context, event *models.SecurityEvent) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	s.log.WarnContext(ctx, "security event",
+		s.log.Group("audit",
+			s.log.String("type", string(event.Type)),
+			s.log.String("user_id", event.UserID),
+			s.log.String("session_id", event.SessionID),
+			s.log.String("ip_address", event.IpAddress),
+			s.log.String("user_agent", event.UserAgent),
+			s.log.Any("details", event.Details),
+			s.log.Any("occurred_at", event.OccurredAt),
+		),
+	)
+}
@@ -0,0 +1,19 @@
+package authctx
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+)
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p *models.Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// PrincipalFromContext returns the caller put into the context by the auth
+// interceptor, ok is false for public methods.
+func PrincipalFromContext(ctx context.Context) (*models.Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*models.Principal)
+	return p, ok && p != nil
+}
@@ -3,7 +3,31 @@ package jwt
 import "github.com/golang-jwt/jwt/v5"
 
 type Claims struct {
-	UserID    string `json:"uid"`
-	SessionID string `json:"sid"`
+	UserID    string   `json:"uid"`
+	SessionID string   `json:"sid"`
+	Roles     []string `json:"roles,omitempty"`
+	Scopes    []string `json:"scp,omitempty"`
+	// Type is "access", "refresh" or "action", so a token is only
+	// accepted for what it was issued for.
+	Type string `json:"typ"`
+	// Purpose and Email are only set on action tokens.
+	Purpose string `json:"pur,omitempty"`
+	Email   string `json:"email,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// TokenOption adds optional claims to an access token.
+type TokenOption func(c *Claims)
+
+func WithRoles(roles ...string) TokenOption {
+	return func(c *Claims) {
+		c.Roles = roles
+	}
+}
+
+// WithScopes sets the permissions granted to the token holder.
+func WithScopes(scopes ...string) TokenOption {
+	return func(c *Claims) {
+		c.Scopes = scopes
+	}
+}
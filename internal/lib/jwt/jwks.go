@@ -0,0 +1,115 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK is the public part of a signing key as described in RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS builds the document published at /.well-known/jwks.json. HMAC keys are
+// never published.
+func (s *KeySet) JWKS() *JWKS {
+	res := &JWKS{Keys: []JWK{}}
+	for _, key := range s.Verifiable() {
+		jwk, ok := publicJWK(key.verifyKey)
+		if !ok {
+			continue
+		}
+		jwk.Kid = key.ID
+		jwk.Alg = key.Algorithm()
+		jwk.Use = "sig"
+		res.Keys = append(res.Keys, jwk)
+	}
+	return res
+}
+
+func publicJWK(key any) (JWK, bool) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   b64(k.N.Bytes()),
+			E:   b64(big.NewInt(int64(k.E)).Bytes()),
+		}, true
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Crv: k.Curve.Params().Name,
+			X:   b64(k.X.FillBytes(make([]byte, size))),
+			Y:   b64(k.Y.FillBytes(make([]byte, size))),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   b64(k),
+		}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint, used as kid when none is
+// configured explicitly.
+func thumbprint(key any) (string, error) {
+	jwk, ok := publicJWK(key)
+	if !ok {
+		return "", fmt.Errorf("%w: cannot compute thumbprint", ErrUnsupportedAlgorithm)
+	}
+	// required members only, in lexicographic order
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return b64(sum[:]), nil
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
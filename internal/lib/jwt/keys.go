@@ -0,0 +1,203 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"strings"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrMissingKey           = errors.New("signing key is not configured")
+	ErrUnknownKey           = errors.New("unknown signing key")
+	ErrKeyMismatch          = errors.New("public key does not match private key")
+)
+
+// Key is a single entry of the key set. For HMAC both signKey and verifyKey
+// are the shared secret, for asymmetric algorithms verifyKey is the public
+// half of signKey. Keys loaded from a public key only have no signKey and can
+// be used for verification only.
+type Key struct {
+	ID        string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	retired   bool
+}
+
+func (k *Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+func (k *Key) canSign() bool {
+	return k.signKey != nil
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	switch alg {
+	case "", "HS256":
+		return jwt.SigningMethodHS256, nil
+	case "RS256":
+		return jwt.SigningMethodRS256, nil
+	case "ES256":
+		return jwt.SigningMethodES256, nil
+	case "EdDSA":
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// legacyKeyConfig describes the single key configured through the top level
+// JWT_* variables, which predate the key set.
+func legacyKeyConfig(conf *config.JWTConfig) (config.JWTKeyConfig, bool) {
+	if conf.Secret == "" && conf.PrivateKeyPath == "" {
+		return config.JWTKeyConfig{}, false
+	}
+	return config.JWTKeyConfig{
+		ID:             conf.KeyID,
+		Algorithm:      conf.Algorithm,
+		PrivateKeyPath: conf.PrivateKeyPath,
+		PublicKeyPath:  conf.PublicKeyPath,
+	}, true
+}
+
+func loadKey(conf config.JWTKeyConfig, secret string) (*Key, error) {
+	method, err := signingMethod(conf.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{ID: conf.ID, method: method, retired: conf.Retired}
+
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if conf.SecretPath != "" {
+			data, err := os.ReadFile(conf.SecretPath)
+			if err != nil {
+				return nil, fmt.Errorf("read secret: %w", err)
+			}
+			secret = strings.TrimSpace(string(data))
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("%w: secret is empty", ErrMissingKey)
+		}
+		key.signKey = []byte(secret)
+		key.verifyKey = []byte(secret)
+		return key, nil
+	}
+
+	if conf.PrivateKeyPath != "" {
+		privatePEM, err := os.ReadFile(conf.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read private key: %w", err)
+		}
+		key.signKey, err = parsePrivateKey(method, privatePEM)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case conf.PublicKeyPath != "":
+		publicPEM, err := os.ReadFile(conf.PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("read public key: %w", err)
+		}
+		key.verifyKey, err = parsePublicKey(method, publicPEM)
+		if err != nil {
+			return nil, err
+		}
+	case key.signKey != nil:
+		key.verifyKey = key.signKey.(crypto.Signer).Public()
+	default:
+		return nil, fmt.Errorf("%w: neither private nor public key path is set", ErrMissingKey)
+	}
+
+	if !keyMatchesMethod(method, key.verifyKey) {
+		return nil, fmt.Errorf("%w: key does not match %s", ErrUnsupportedAlgorithm, method.Alg())
+	}
+	// a mismatched pair would load fine and fail every token at runtime
+	if key.signKey != nil && !keysPair(key.signKey.(crypto.Signer), key.verifyKey) {
+		return nil, ErrKeyMismatch
+	}
+	if key.ID == "" {
+		key.ID, err = thumbprint(key.verifyKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func parsePrivateKey(method jwt.SigningMethod, data []byte) (any, error) {
+	var (
+		key any
+		err error
+	)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA:
+		key, err = jwt.ParseRSAPrivateKeyFromPEM(data)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPrivateKeyFromPEM(data)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPrivateKeyFromPEM(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, method.Alg())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	return key, nil
+}
+
+func parsePublicKey(method jwt.SigningMethod, data []byte) (any, error) {
+	var (
+		key any
+		err error
+	)
+	switch method.(type) {
+	case *jwt.SigningMethodRSA:
+		key, err = jwt.ParseRSAPublicKeyFromPEM(data)
+	case *jwt.SigningMethodECDSA:
+		key, err = jwt.ParseECPublicKeyFromPEM(data)
+	case *jwt.SigningMethodEd25519:
+		key, err = jwt.ParseEdPublicKeyFromPEM(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, method.Alg())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	return key, nil
+}
+
+// keyMatchesMethod guards against a PEM file of the wrong type being paired
+// with the configured algorithm (e.g. an RSA key with ES256).
+func keyMatchesMethod(method jwt.SigningMethod, key any) bool {
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok := key.([]byte)
+		return ok
+	case *jwt.SigningMethodRSA:
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		k, ok := key.(*ecdsa.PublicKey)
+		return ok && k.Curve.Params().BitSize == 256
+	case *jwt.SigningMethodEd25519:
+		_, ok := key.(ed25519.PublicKey)
+		return ok
+	}
+	return false
+}
+
+func keysPair(private crypto.Signer, public any) bool {
+	pub, ok := private.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(public)
+}
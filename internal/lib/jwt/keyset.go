@@ -0,0 +1,94 @@
+package jwt
+
+import (
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"sort"
+)
+
+const defaultKeyID = "default"
+
+// KeySet holds every key the manager knows about. Tokens are always signed
+// with the active key, while any key that is not retired is accepted for
+// verification, so a new key can be rolled out without invalidating tokens
+// issued by the previous one.
+type KeySet struct {
+	keys   map[string]*Key
+	active *Key
+}
+
+func NewKeySet(conf *config.JWTConfig) (*KeySet, error) {
+	set := &KeySet{keys: make(map[string]*Key)}
+
+	if legacy, ok := legacyKeyConfig(conf); ok {
+		key, err := loadKey(legacy, conf.Secret)
+		if err != nil {
+			return nil, err
+		}
+		if key.ID == "" {
+			key.ID = defaultKeyID
+		}
+		set.keys[key.ID] = key
+		set.active = key
+	}
+
+	for _, kc := range conf.Keys {
+		if kc.ID == "" {
+			return nil, fmt.Errorf("%w: kid is required for every entry in jwt.keys", ErrMissingKey)
+		}
+		if _, ok := set.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", kc.ID)
+		}
+		key, err := loadKey(kc, "")
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", kc.ID, err)
+		}
+		set.keys[key.ID] = key
+	}
+
+	if conf.ActiveKeyID != "" {
+		key, ok := set.keys[conf.ActiveKeyID]
+		if !ok {
+			return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, conf.ActiveKeyID)
+		}
+		set.active = key
+	}
+	if set.active == nil {
+		return nil, fmt.Errorf("%w: no active key", ErrMissingKey)
+	}
+	if !set.active.canSign() || set.active.retired {
+		return nil, fmt.Errorf("active key %q cannot be used for signing", set.active.ID)
+	}
+
+	return set, nil
+}
+
+// Active returns the key new tokens are signed with.
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+// Lookup finds a verification key by kid. Tokens issued before kid headers
+// were introduced carry no kid and are checked against the active key.
+func (s *KeySet) Lookup(kid string) (*Key, error) {
+	if kid == "" {
+		return s.active, nil
+	}
+	key, ok := s.keys[kid]
+	if !ok || key.retired {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, kid)
+	}
+	return key, nil
+}
+
+// Verifiable returns all keys that are still accepted, ordered by kid.
+func (s *KeySet) Verifiable() []*Key {
+	res := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		if !key.retired {
+			res = append(res, key)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
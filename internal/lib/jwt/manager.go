@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/Roflan4eg/auth-serivce/config"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"time"
 )
 
@@ -13,10 +14,19 @@ var (
 	ErrTokenMalformed     = errors.New("token malformed")
 	ErrTokenExpired       = errors.New("token expired")
 	ErrTokenNotValidYet   = errors.New("token not valid yet")
+	ErrInvalidIssuer      = errors.New("token issuer is invalid")
+	ErrInvalidAudience    = errors.New("token audience is invalid")
+)
+
+const (
+	typeAccess  = "access"
+	typeRefresh = "refresh"
+	typeAction  = "action"
 )
 
 type Manager struct {
 	conf *config.JWTConfig
+	keys *KeySet
 }
 
 func NewManager(conf *config.JWTConfig) (*Manager, error) {
@@ -26,57 +36,141 @@ func NewManager(conf *config.JWTConfig) (*Manager, error) {
 	if conf.AccessTokenTTL >= conf.RefreshTokenTTL {
 		return nil, errors.New("access expiration must be less than refresh")
 	}
-	return &Manager{conf: conf}, nil
+	keys, err := NewKeySet(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{conf: conf, keys: keys}, nil
 }
 
-func (m *Manager) GenerateAccessToken(userID, sessionID string) (string, error) {
-
-	claims := &Claims{
-		SessionID: sessionID,
-		UserID:    userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.conf.AccessTokenTTL)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			//NotBefore: jwt.NewNumericDate(time.Now()),
-			//Issuer:    "auth-service",
-			//Subject:   userID,
-			//ID:        sessionID,
-		},
+func (m *Manager) GenerateAccessToken(userID, sessionID string, opts ...TokenOption) (string, error) {
+	claims, err := m.newClaims(typeAccess, userID, sessionID, m.conf.AccessTokenTTL, m.conf.Audience)
+	if err != nil {
+		return "", ErrFailedGen
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	res, err := token.SignedString([]byte(m.conf.Secret))
+	for _, opt := range opts {
+		opt(claims)
+	}
+	res, err := m.sign(claims)
 	if err != nil {
 		return "", ErrFailedGen
 	}
 	return res, nil
 }
 
+// GenerateRefreshToken issues a refresh token. Refresh tokens are only ever
+// consumed by this service, so their audience is a dedicated one below the
+// issuer, e.g. auth-service/refresh, and they are rejected by consumers
+// expecting an access token audience.
 func (m *Manager) GenerateRefreshToken(userID, sessionID string) (string, error) {
-	claims := &Claims{
+	claims, err := m.newClaims(typeRefresh, userID, sessionID, m.conf.RefreshTokenTTL, []string{m.audienceFor(typeRefresh)})
+	if err != nil {
+		return "", ErrFailedGen
+	}
+	return m.sign(claims)
+}
+
+// GenerateActionToken issues a token authorizing a single action of the
+// user, e.g. confirming an email address. Its audience is bound to the
+// purpose, so it is never accepted as an access or refresh token or for a
+// different action.
+func (m *Manager) GenerateActionToken(purpose, userID, email string, ttl time.Duration) (string, *Claims, error) {
+	claims, err := m.newClaims(typeAction, userID, "", ttl, []string{m.audienceFor(purpose)})
+	if err != nil {
+		return "", nil, ErrFailedGen
+	}
+	claims.Purpose = purpose
+	claims.Email = email
+	res, err := m.sign(claims)
+	if err != nil {
+		return "", nil, ErrFailedGen
+	}
+	return res, claims, nil
+}
+
+func (m *Manager) newClaims(typ, userID, sessionID string, ttl time.Duration, audience []string) (*Claims, error) {
+	jti, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Claims{
 		SessionID: sessionID,
 		UserID:    userID,
+		Type:      typ,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.conf.RefreshTokenTTL)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			//NotBefore: jwt.NewNumericDate(time.Now()),
-			//Issuer:    "auth-service",
-			//Subject:   userID,
-			//ID:        sessionID,
+			Issuer:    m.conf.Issuer,
+			Subject:   userID,
+			Audience:  audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti.String(),
 		},
+	}, nil
+}
+
+func (m *Manager) sign(claims *Claims) (string, error) {
+	key := m.keys.Active()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// ValidateToken verifies an access token. The token must carry one of the
+// given audiences, or one of the configured accepted audiences when none are
+// given. Refresh and action tokens are rejected whatever their audience.
+func (m *Manager) ValidateToken(tokenString string, audience ...string) (*Claims, error) {
+	if len(audience) == 0 {
+		audience = m.acceptedAudiences()
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return m.validate(tokenString, typeAccess, audience)
+}
+
+func (m *Manager) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	return m.validate(tokenString, typeRefresh, []string{m.audienceFor(typeRefresh)})
+}
+
+func (m *Manager) ValidateActionToken(tokenString, purpose string) (*Claims, error) {
+	claims, err := m.validate(tokenString, typeAction, []string{m.audienceFor(purpose)})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, ErrInvalidAudience
+	}
+	return claims, nil
+}
 
-	return token.SignedString([]byte(m.conf.Secret))
+// audienceFor returns the audience of tokens for one purpose of this service,
+// e.g. auth-service/refresh.
+func (m *Manager) audienceFor(purpose string) string {
+	if m.conf.Issuer == "" {
+		return purpose
+	}
+	return m.conf.Issuer + "/" + purpose
 }
 
-func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
+func (m *Manager) validate(tokenString, typ string, audience []string) (*Claims, error) {
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired()}
+	if m.conf.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.conf.Issuer))
+	}
+	if len(audience) > 0 {
+		opts = append(opts, jwt.WithAudience(audience...))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := m.keys.Lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Algorithm() {
 			return nil, ErrInvalidTokenFormat
 		}
-		return []byte(m.conf.Secret), nil
-	})
+		return key.verifyKey, nil
+	}, opts...)
 
 	if err != nil {
 		switch {
@@ -86,6 +180,10 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 			return nil, ErrTokenExpired
 		case errors.Is(err, jwt.ErrTokenNotValidYet):
 			return nil, ErrTokenNotValidYet
+		case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+			return nil, ErrInvalidIssuer
+		case errors.Is(err, jwt.ErrTokenInvalidAudience):
+			return nil, ErrInvalidAudience
 		default:
 			return nil, ErrInvalidTokenFormat
 		}
@@ -99,10 +197,30 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 	if !ok {
 		return nil, ErrInvalidTokenFormat
 	}
+	if claims.Type != typ {
+		return nil, ErrInvalidAudience
+	}
 
 	return claims, nil
 }
 
+func (m *Manager) acceptedAudiences() []string {
+	if len(m.conf.AcceptedAudiences) > 0 {
+		return m.conf.AcceptedAudiences
+	}
+	return m.conf.Audience
+}
+
+// Algorithm returns the JWS "alg" used for issued tokens.
+func (m *Manager) Algorithm() string {
+	return m.keys.Active().Algorithm()
+}
+
+// JWKS returns the public keys consumers need to verify issued tokens.
+func (m *Manager) JWKS() *JWKS {
+	return m.keys.JWKS()
+}
+
 func (m *Manager) GetAccessTokenTTL() time.Duration {
 	return m.conf.AccessTokenTTL
 }
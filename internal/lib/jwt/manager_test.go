@@ -1,9 +1,20 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -124,7 +135,7 @@ func TestManager_GenerateRefreshToken(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 
-	claims, err := manager.ValidateToken(token)
+	claims, err := manager.ValidateRefreshToken(token)
 	assert.NoError(t, err)
 	assert.Equal(t, uid, claims.UserID)
 	assert.Equal(t, sesId, claims.SessionID)
@@ -211,8 +222,8 @@ func TestManager_ValidateToken(t *testing.T) {
 func TestManager_TokenExpiration(t *testing.T) {
 	cfg := &config.JWTConfig{
 		Secret:          "test-secret",
-		AccessTokenTTL:  500 * time.Millisecond,
-		RefreshTokenTTL: time.Second,
+		AccessTokenTTL:  1500 * time.Millisecond,
+		RefreshTokenTTL: 3 * time.Second,
 	}
 
 	manager, err := NewManager(cfg)
@@ -228,7 +239,9 @@ func TestManager_TokenExpiration(t *testing.T) {
 	})
 
 	t.Run("token expired", func(t *testing.T) {
-		time.Sleep(550 * time.Millisecond)
+		// expiry is truncated to whole seconds, so the token expires
+		// between 500ms and 1.5s after issue
+		time.Sleep(1600 * time.Millisecond)
 
 		claims, err := manager.ValidateToken(token)
 		assert.Error(t, err)
@@ -236,3 +249,363 @@ func TestManager_TokenExpiration(t *testing.T) {
 		assert.Nil(t, claims)
 	})
 }
+
+func writeKeyPair(t *testing.T, signer crypto.Signer) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(signer)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	require.NoError(t, err)
+
+	privPath := filepath.Join(dir, "private.pem")
+	pubPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0600))
+	return privPath, pubPath
+}
+
+func TestManager_AsymmetricAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg    string
+		signer crypto.Signer
+	}{
+		{alg: "RS256", signer: rsaKey},
+		{alg: "ES256", signer: ecKey},
+		{alg: "EdDSA", signer: edKey},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.alg, func(t *testing.T) {
+			privPath, pubPath := writeKeyPair(t, tc.signer)
+
+			signer, err := NewManager(&config.JWTConfig{
+				KeyID:           "signer",
+				Algorithm:       tc.alg,
+				PrivateKeyPath:  privPath,
+				AccessTokenTTL:  15 * time.Minute,
+				RefreshTokenTTL: 24 * time.Hour,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.alg, signer.Algorithm())
+
+			token, err := signer.GenerateAccessToken("user-123", "session-456")
+			require.NoError(t, err)
+
+			// a consumer holding only the public key can verify the token,
+			// its own signing key is unrelated
+			verifier, err := NewManager(&config.JWTConfig{
+				Secret: "verifier-secret",
+				Keys: []config.JWTKeyConfig{
+					{ID: "signer", Algorithm: tc.alg, PublicKeyPath: pubPath},
+				},
+				AccessTokenTTL:  15 * time.Minute,
+				RefreshTokenTTL: 24 * time.Hour,
+			})
+			require.NoError(t, err)
+			claims, err := verifier.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, "user-123", claims.UserID)
+
+			hmacManager, err := NewManager(&config.JWTConfig{
+				Secret:          "secret",
+				AccessTokenTTL:  15 * time.Minute,
+				RefreshTokenTTL: 24 * time.Hour,
+			})
+			require.NoError(t, err)
+			_, err = hmacManager.ValidateToken(token)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("key does not match algorithm", func(t *testing.T) {
+		privPath, _ := writeKeyPair(t, rsaKey)
+		_, err := NewManager(&config.JWTConfig{
+			Algorithm:       "ES256",
+			PrivateKeyPath:  privPath,
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("public key does not match private key", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		privPath, _ := writeKeyPair(t, ecKey)
+		_, otherPub := writeKeyPair(t, otherKey)
+		_, err = NewManager(&config.JWTConfig{
+			Algorithm:       "ES256",
+			PrivateKeyPath:  privPath,
+			PublicKeyPath:   otherPub,
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		})
+		assert.ErrorIs(t, err, ErrKeyMismatch)
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := NewManager(&config.JWTConfig{
+			Secret:          "secret",
+			Algorithm:       "none",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		})
+		assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	})
+}
+
+func TestManager_KeyRotation(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	oldPriv, oldPub := writeKeyPair(t, oldKey)
+	newPriv, _ := writeKeyPair(t, newKey)
+
+	before, err := NewManager(&config.JWTConfig{
+		ActiveKeyID: "old",
+		Keys: []config.JWTKeyConfig{
+			{ID: "old", Algorithm: "ES256", PrivateKeyPath: oldPriv},
+		},
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	oldToken, err := before.GenerateAccessToken("user-123", "session-456")
+	require.NoError(t, err)
+
+	rotated, err := NewManager(&config.JWTConfig{
+		ActiveKeyID: "new",
+		Keys: []config.JWTKeyConfig{
+			{ID: "new", Algorithm: "ES256", PrivateKeyPath: newPriv},
+			{ID: "old", Algorithm: "ES256", PublicKeyPath: oldPub},
+		},
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	t.Run("token signed by previous key is still valid", func(t *testing.T) {
+		claims, err := rotated.ValidateToken(oldToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user-123", claims.UserID)
+	})
+
+	t.Run("new tokens carry the active kid", func(t *testing.T) {
+		token, err := rotated.GenerateAccessToken("user-123", "session-456")
+		require.NoError(t, err)
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		require.NoError(t, err)
+		assert.Equal(t, "new", parsed.Header["kid"])
+	})
+
+	t.Run("jwks lists every accepted key", func(t *testing.T) {
+		jwks := rotated.JWKS()
+		require.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "new", jwks.Keys[0].Kid)
+		assert.Equal(t, "old", jwks.Keys[1].Kid)
+		assert.Equal(t, "EC", jwks.Keys[0].Kty)
+		assert.Equal(t, "P-256", jwks.Keys[0].Crv)
+	})
+
+	t.Run("retired key is rejected", func(t *testing.T) {
+		retired, err := NewManager(&config.JWTConfig{
+			ActiveKeyID: "new",
+			Keys: []config.JWTKeyConfig{
+				{ID: "new", Algorithm: "ES256", PrivateKeyPath: newPriv},
+				{ID: "old", Algorithm: "ES256", PublicKeyPath: oldPub, Retired: true},
+			},
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		})
+		require.NoError(t, err)
+		_, err = retired.ValidateToken(oldToken)
+		assert.Error(t, err)
+		assert.Len(t, retired.JWKS().Keys, 1)
+	})
+
+	t.Run("hmac keys are not published", func(t *testing.T) {
+		m, err := NewManager(&config.JWTConfig{
+			Secret:          "secret",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, m.JWKS().Keys)
+	})
+}
+
+func TestManager_RegisteredClaims(t *testing.T) {
+	cfg := &config.JWTConfig{
+		Secret:            "test-secret-key-123",
+		Issuer:            "auth-service",
+		Audience:          []string{"web", "mobile"},
+		AcceptedAudiences: []string{"web", "mobile", "billing"},
+		AccessTokenTTL:    15 * time.Minute,
+		RefreshTokenTTL:   24 * time.Hour,
+	}
+	manager, err := NewManager(cfg)
+	require.NoError(t, err)
+
+	token, err := manager.GenerateAccessToken("user-123", "session-456")
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "auth-service", claims.Issuer)
+	assert.Equal(t, "user-123", claims.Subject)
+	assert.Equal(t, jwt.ClaimStrings{"web", "mobile"}, claims.Audience)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.NotBefore)
+
+	t.Run("jti is unique per token", func(t *testing.T) {
+		other, err := manager.GenerateAccessToken("user-123", "session-456")
+		require.NoError(t, err)
+		otherClaims, err := manager.ValidateToken(other)
+		require.NoError(t, err)
+		assert.NotEqual(t, claims.ID, otherClaims.ID)
+	})
+
+	t.Run("requested audience", func(t *testing.T) {
+		_, err := manager.ValidateToken(token, "mobile")
+		assert.NoError(t, err)
+		_, err = manager.ValidateToken(token, "billing")
+		assert.ErrorIs(t, err, ErrInvalidAudience)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		otherIssuer, err := NewManager(&config.JWTConfig{
+			Secret:          cfg.Secret,
+			Issuer:          "someone-else",
+			Audience:        cfg.Audience,
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		})
+		require.NoError(t, err)
+		_, err = otherIssuer.ValidateToken(token)
+		assert.ErrorIs(t, err, ErrInvalidIssuer)
+	})
+
+	t.Run("audience not accepted", func(t *testing.T) {
+		strict, err := NewManager(&config.JWTConfig{
+			Secret:          cfg.Secret,
+			Issuer:          cfg.Issuer,
+			Audience:        []string{"admin"},
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		})
+		require.NoError(t, err)
+		_, err = strict.ValidateToken(token)
+		assert.ErrorIs(t, err, ErrInvalidAudience)
+	})
+
+	t.Run("refresh token is not an access token", func(t *testing.T) {
+		refresh, err := manager.GenerateRefreshToken("user-123", "session-456")
+		require.NoError(t, err)
+		_, err = manager.ValidateToken(refresh)
+		assert.ErrorIs(t, err, ErrInvalidAudience)
+		_, err = manager.ValidateRefreshToken(refresh)
+		assert.NoError(t, err)
+		_, err = manager.ValidateRefreshToken(token)
+		assert.ErrorIs(t, err, ErrInvalidAudience)
+	})
+}
+
+func TestManager_AccessTokenOptions(t *testing.T) {
+	manager, err := NewManager(&config.JWTConfig{
+		Secret:          "test-secret-key-123",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	token, err := manager.GenerateAccessToken("user-123", "session-456",
+		WithRoles("admin"),
+		WithScopes("users:read", "roles:manage"),
+	)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+	assert.Equal(t, []string{"users:read", "roles:manage"}, claims.Scopes)
+}
+
+func TestManager_ActionToken(t *testing.T) {
+	manager, err := NewManager(&config.JWTConfig{
+		Secret:          "test-secret-key-123",
+		Issuer:          "auth-service",
+		Audience:        []string{"auth-service"},
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 24 * time.Hour,
+	})
+	require.NoError(t, err)
+
+	token, issued, err := manager.GenerateActionToken("email_change", "user-123", "new@example.com", time.Hour)
+	require.NoError(t, err)
+	assert.NotEmpty(t, issued.ID)
+
+	claims, err := manager.ValidateActionToken(token, "email_change")
+	require.NoError(t, err)
+	assert.Equal(t, "user-123", claims.UserID)
+	assert.Equal(t, "new@example.com", claims.Email)
+	assert.Equal(t, issued.ID, claims.ID)
+
+	_, err = manager.ValidateActionToken(token, "email_verification")
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+	_, err = manager.ValidateToken(token)
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+	_, err = manager.ValidateRefreshToken(token)
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+
+	access, err := manager.GenerateAccessToken("user-123", "session-456")
+	require.NoError(t, err)
+	_, err = manager.ValidateActionToken(access, "email_change")
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+}
+
+func TestManager_RefreshTokenIsNoAccessToken(t *testing.T) {
+	configs := map[string]*config.JWTConfig{
+		"shipped config": {
+			Secret:          "test-secret-key-123",
+			Issuer:          "auth-service",
+			Audience:        []string{"auth-service"},
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		},
+		"no issuer or audience": {
+			Secret:          "test-secret-key-123",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 24 * time.Hour,
+		},
+	}
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			manager, err := NewManager(cfg)
+			require.NoError(t, err)
+
+			refresh, err := manager.GenerateRefreshToken("user-123", "session-456")
+			require.NoError(t, err)
+			_, err = manager.ValidateToken(refresh)
+			assert.ErrorIs(t, err, ErrInvalidAudience)
+			_, err = manager.ValidateToken(refresh, cfg.Issuer)
+			assert.ErrorIs(t, err, ErrInvalidAudience)
+			_, err = manager.ValidateRefreshToken(refresh)
+			assert.NoError(t, err)
+
+			access, err := manager.GenerateAccessToken("user-123", "session-456")
+			require.NoError(t, err)
+			_, err = manager.ValidateRefreshToken(access)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -80,6 +80,10 @@ func (l *Logger) Any(key string, value any) slog.Attr {
 	return slog.Any(key, value)
 }
 
+func (l *Logger) Group(key string, args ...any) slog.Attr {
+	return slog.Group(key, args...)
+}
+
 func (l *Logger) Duration(key string, value time.Duration) slog.Attr {
 	return slog.Duration(key, value)
 }
@@ -176,6 +180,11 @@ func (e *errorWithLogCtx) Error() string {
 	return e.next.Error()
 }
 
+// Unwrap lets errors.Is and errors.As see the wrapped error.
+func (e *errorWithLogCtx) Unwrap() error {
+	return e.next
+}
+
 func WrapError(ctx context.Context, err error) error {
 	var e *errorWithLogCtx
 	if errors.As(err, &e) {
@@ -0,0 +1,19 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestWrapError_KeepsErrorChain(t *testing.T) {
+	errNotFound := errors.New("not found")
+	ctx := WithData(context.Background(), map[string]any{"uid": "user-123"})
+
+	err := WrapError(ctx, fmt.Errorf("repository: %w", errNotFound))
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Equal(t, "repository: not found", err.Error())
+	assert.ErrorIs(t, OriginalError(err), errNotFound)
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileMailer appends every message to a file, one after another. Tests and
+// local setups use it to read the tokens that would otherwise be mailed.
+type FileMailer struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{path: path, from: from}
+}
+
+func (m *FileMailer) Send(_ context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open mail file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err = f.Write(format(m.from, msg)); err != nil {
+		return fmt.Errorf("write mail file: %w", err)
+	}
+	return nil
+}
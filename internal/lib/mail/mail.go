@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New builds the sender selected by conf.Driver.
+func New(conf *config.MailConfig, log *logger.Logger) (Sender, error) {
+	switch conf.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(conf)
+	case DriverFile:
+		return NewFileMailer(conf.FilePath, conf.From), nil
+	case DriverLog, "":
+		return NewLogMailer(log), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", conf.Driver)
+	}
+}
+
+// LogMailer writes messages to the service log instead of delivering them.
+// Meant for local development, where no mail server is available.
+type LogMailer struct {
+	log *logger.Logger
+}
+
+func NewLogMailer(log *logger.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	m.log.InfoContext(ctx, "mail sent",
+		m.log.Group("mail",
+			m.log.String("to", msg.To),
+			m.log.String("subject", msg.Subject),
+			m.log.String("body", msg.Body),
+		),
+	)
+	return nil
+}
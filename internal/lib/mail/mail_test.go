@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	sender, err := New(&config.MailConfig{Driver: DriverFile, FilePath: "mail.log"}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &FileMailer{}, sender)
+
+	_, err = New(&config.MailConfig{Driver: DriverSMTP}, nil)
+	assert.Error(t, err, "smtp without host")
+
+	_, err = New(&config.MailConfig{Driver: "pigeon"}, nil)
+	assert.Error(t, err)
+}
+
+func TestFileMailer_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	mailer := NewFileMailer(path, "no-reply@example.com")
+
+	require.NoError(t, mailer.Send(context.Background(), EmailVerification("a@example.com", "https://example.com/verify?lang=en", "tok")))
+	require.NoError(t, mailer.Send(context.Background(), EmailChanged("b@example.com", "c@example.com")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "From: no-reply@example.com\r\n")
+	assert.Contains(t, content, "To: a@example.com\r\n")
+	assert.Contains(t, content, "https://example.com/verify?lang=en&token=tok")
+	assert.Contains(t, content, "To: b@example.com\r\n")
+}
+
+func TestWithToken(t *testing.T) {
+	assert.Equal(t, "tok", withToken("", "tok"))
+	assert.Equal(t, "https://example.com/confirm?token=a%2Bb", withToken("https://example.com/confirm", "a+b"))
+}
@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer delivers messages through an SMTP relay. Authentication is only
+// used when a username is configured, net/smtp refuses to send credentials
+// over a connection without TLS unless the server is on localhost.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPMailer(conf *config.MailConfig) (*SMTPMailer, error) {
+	if conf.SMTPHost == "" {
+		return nil, errors.New("smtp host is not configured")
+	}
+	if conf.From == "" {
+		return nil, errors.New("mail sender address is not configured")
+	}
+	m := &SMTPMailer{
+		addr: net.JoinHostPort(conf.SMTPHost, conf.SMTPPort),
+		from: conf.From,
+	}
+	if conf.SMTPUsername != "" {
+		m.auth = smtp.PlainAuth("", conf.SMTPUsername, conf.SMTPPassword, conf.SMTPHost)
+	}
+	return m, nil
+}
+
+// Send delivers msg. smtp.SendMail can't be cancelled, the context is only
+// checked before dialing.
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, format(m.from, msg)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
+
+// format renders msg as a plain text RFC 5322 message.
+func format(from string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// EmailVerification asks a newly registered user to confirm the address.
+func EmailVerification(to, verifyURL, token string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Welcome! Please confirm this is your email address.\n\n"+
+			"To verify, open %s\n\n"+
+			"If you didn't create an account, ignore this message.", withToken(verifyURL, token)),
+	}
+}
+
+// EmailChange asks the owner of the new address to confirm the change.
+func EmailChange(to, confirmURL, token string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Someone asked to use this address for their account.\n\n"+
+			"To confirm, open %s\n\n"+
+			"If it wasn't you, ignore this message.", withToken(confirmURL, token)),
+	}
+}
+
+// EmailChanged notifies the previous address of a completed change.
+func EmailChanged(to, newEmail string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("The email address of your account was changed to %s.\n\n"+
+			"If you didn't do this, contact support immediately.", newEmail),
+	}
+}
+
+// DuplicateRegistration tells the owner of an address that someone tried to
+// register it again.
+func DuplicateRegistration(to, resetURL string) *Message {
+	body := "Someone tried to create an account with this email address, but you already have one.\n\n" +
+		"If it was you, sign in instead"
+	if resetURL != "" {
+		body += ", or choose a new password at " + resetURL
+	}
+	return &Message{
+		To:      to,
+		Subject: "You already have an account",
+		Body:    body + ".\n\nIf it wasn't you, ignore this message.",
+	}
+}
+
+// PasswordReset sends the link to choose a new password.
+func PasswordReset(to, resetURL, token string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("We received a request to reset the password of your account.\n\n"+
+			"To choose a new password, open %s\n\n"+
+			"If you didn't ask for this, ignore this message, your password stays unchanged.", withToken(resetURL, token)),
+	}
+}
+
+// PasswordlessLink sends the link that signs the user in.
+func PasswordlessLink(to, loginURL, token string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Your sign-in link",
+		Body: fmt.Sprintf("To sign in, open %s\n\n"+
+			"The link can be used once. If you didn't try to sign in, ignore this message.", withToken(loginURL, token)),
+	}
+}
+
+// PasswordlessCode sends the code that signs the user in.
+func PasswordlessCode(to, code string, ttl time.Duration) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Your sign-in code",
+		Body: fmt.Sprintf("Your sign-in code is %s\n\n"+
+			"It expires in %s. If you didn't try to sign in, ignore this message.", code, ttl),
+	}
+}
+
+// withToken appends the token to link as a query parameter. Without a link
+// the bare token is used, to be entered by hand.
+func withToken(link, token string) string {
+	if link == "" {
+		return token
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return token
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"time"
+)
+
+// countTimeout bounds the count of active sessions made on every scrape.
+const countTimeout = 5 * time.Second
+
+// PgxPoolCollector exports the connection pool statistics of Postgres.
+type PgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	total, idle, acquired *prometheus.Desc
+	acquires, waits       *prometheus.Desc
+	waitDuration          *prometheus.Desc
+}
+
+func NewPgxPoolCollector(pool *pgxpool.Pool) *PgxPoolCollector {
+	return &PgxPoolCollector{
+		pool:         pool,
+		total:        prometheus.NewDesc("postgres_pool_connections", "Number of open connections", nil, nil),
+		idle:         prometheus.NewDesc("postgres_pool_idle_connections", "Number of idle connections", nil, nil),
+		acquired:     prometheus.NewDesc("postgres_pool_acquired_connections", "Number of connections in use", nil, nil),
+		acquires:     prometheus.NewDesc("postgres_pool_acquires_total", "Total number of connection acquires", nil, nil),
+		waits:        prometheus.NewDesc("postgres_pool_empty_acquires_total", "Total number of acquires that waited for a connection", nil, nil),
+		waitDuration: prometheus.NewDesc("postgres_pool_acquire_wait_seconds_total", "Total time spent waiting for a connection", nil, nil),
+	}
+}
+
+func (c *PgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.total
+	ch <- c.idle
+	ch <- c.acquired
+	ch <- c.acquires
+	ch <- c.waits
+	ch <- c.waitDuration
+}
+
+func (c *PgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+// RedisPoolCollector exports the connection pool statistics of Redis.
+type RedisPoolCollector struct {
+	client *redis.Client
+
+	total, idle            *prometheus.Desc
+	hits, misses, timeouts *prometheus.Desc
+	stale                  *prometheus.Desc
+}
+
+func NewRedisPoolCollector(client *redis.Client) *RedisPoolCollector {
+	return &RedisPoolCollector{
+		client:   client,
+		total:    prometheus.NewDesc("redis_pool_connections", "Number of open connections", nil, nil),
+		idle:     prometheus.NewDesc("redis_pool_idle_connections", "Number of idle connections", nil, nil),
+		hits:     prometheus.NewDesc("redis_pool_hits_total", "Total number of times a free connection was found", nil, nil),
+		misses:   prometheus.NewDesc("redis_pool_misses_total", "Total number of times a connection had to be opened", nil, nil),
+		timeouts: prometheus.NewDesc("redis_pool_timeouts_total", "Total number of times waiting for a connection timed out", nil, nil),
+		stale:    prometheus.NewDesc("redis_pool_stale_connections_total", "Total number of stale connections removed", nil, nil),
+	}
+}
+
+func (c *RedisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.total
+	ch <- c.idle
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.stale
+}
+
+func (c *RedisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stat.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stat.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stat.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.stale, prometheus.CounterValue, float64(stat.StaleConns))
+}
+
+// SessionCounter counts the sessions that have not expired or been revoked.
+type SessionCounter interface {
+	Count(ctx context.Context) (int, error)
+}
+
+// NewActiveSessionsGauge reports the number of sessions on every scrape.
+// Failed counts are reported as NaN rather than a stale value.
+func NewActiveSessionsGauge(sessions SessionCounter) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "auth_active_sessions",
+		Help: "Number of active sessions",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+		defer cancel()
+		n, err := sessions.Count(ctx)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(n)
+	})
+}
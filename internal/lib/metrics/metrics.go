@@ -3,6 +3,7 @@ package metrics
 import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -29,10 +30,33 @@ var (
 	}, []string{"method"})
 )
 
+var (
+	// Logins counts finished logins by the first factor used (password,
+	// passkey, passwordless or mfa for the second step) and outcome.
+	Logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Total number of logins by method and outcome",
+	}, []string{"method", "outcome"})
+
+	TokenRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_refreshes_total",
+		Help: "Total number of refresh token exchanges by outcome",
+	}, []string{"outcome"})
+
+	TokenValidationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_validation_failures_total",
+		Help: "Total number of rejected access tokens by reason",
+	}, []string{"reason"})
+
+	PasswordHashDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "auth_password_hash_duration_seconds",
+		Help:    "Duration of Argon2id password hashing in seconds",
+		Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	}, []string{"operation"})
+)
+
+// GetStatusCodeFromError returns the name of the gRPC code of err, e.g.
+// "OK" or "InvalidArgument".
 func GetStatusCodeFromError(err error) string {
-	if err == nil {
-		return "OK"
-	}
-	// Для gRPC статусов
-	return "ERROR"
+	return status.Code(err).String()
 }
@@ -0,0 +1,15 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestGetStatusCodeFromError(t *testing.T) {
+	assert.Equal(t, "OK", GetStatusCodeFromError(nil))
+	assert.Equal(t, "NotFound", GetStatusCodeFromError(status.Error(codes.NotFound, "not found")))
+	assert.Equal(t, "Unknown", GetStatusCodeFromError(errors.New("plain error")))
+}
@@ -0,0 +1,155 @@
+package password
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/metrics"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/tracing"
+	"golang.org/x/crypto/argon2"
+	"strings"
+	"time"
+)
+
+// Hashes written before the parameters were configurable are a raw
+// salt||key blob made with these.
+const (
+	legacySaltLength  = 32
+	legacyKeyLength   = 32
+	legacyIterations  = 1
+	legacyMemory      = 64 * 1024
+	legacyParallelism = 4
+)
+
+const phcPrefix = "$argon2id$"
+
+var (
+	ErrInvalidHash         = errors.New("invalid password hash")
+	ErrIncompatibleVersion = errors.New("incompatible argon2 version")
+)
+
+var encoding = base64.RawStdEncoding
+
+type params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+}
+
+// Hasher hashes passwords with Argon2id into PHC strings, e.g.
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>, so every hash records the
+// parameters it was made with.
+type Hasher struct {
+	params params
+}
+
+func NewHasher(conf *config.PasswordHashConfig) *Hasher {
+	return &Hasher{params: params{
+		memory:      conf.Memory,
+		iterations:  conf.Iterations,
+		parallelism: conf.Parallelism,
+		saltLength:  conf.SaltLength,
+		keyLength:   conf.KeyLength,
+	}}
+}
+
+func (h *Hasher) Hash(ctx context.Context, password string) ([]byte, error) {
+	_, span := tracing.Tracer().Start(ctx, "password.hash")
+	defer span.End()
+	salt := make([]byte, h.params.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("hashing error: %w", err)
+	}
+	p := h.params
+	start := time.Now()
+	key := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	metrics.PasswordHashDuration.WithLabelValues("hash").Observe(time.Since(start).Seconds())
+	return []byte(fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		phcPrefix, argon2.Version, p.memory, p.iterations, p.parallelism,
+		encoding.EncodeToString(salt), encoding.EncodeToString(key),
+	)), nil
+}
+
+// Verify reports whether password matches the hash and, if so, whether the
+// hash should be replaced: legacy hashes always, PHC strings when made with
+// weaker parameters than the configured ones. Users without a password
+// have an empty hash, which matches nothing.
+func (h *Hasher) Verify(ctx context.Context, password string, hash []byte) (bool, bool, error) {
+	_, span := tracing.Tracer().Start(ctx, "password.verify")
+	defer span.End()
+	if len(hash) == 0 {
+		return false, false, nil
+	}
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return false, false, err
+	}
+	start := time.Now()
+	otherKey := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	metrics.PasswordHashDuration.WithLabelValues("verify").Observe(time.Since(start).Seconds())
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return false, false, nil
+	}
+	legacy := !bytes.HasPrefix(hash, []byte(phcPrefix))
+	return true, legacy || p.weakerThan(h.params), nil
+}
+
+func (p params) weakerThan(other params) bool {
+	return p.memory < other.memory ||
+		p.iterations < other.iterations ||
+		p.saltLength < other.saltLength ||
+		p.keyLength < other.keyLength
+}
+
+func decode(hash []byte) (params, []byte, []byte, error) {
+	if !bytes.HasPrefix(hash, []byte(phcPrefix)) {
+		return decodeLegacy(hash)
+	}
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, key
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 {
+		return params{}, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params{}, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return params{}, nil, nil, ErrIncompatibleVersion
+	}
+	var p params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return params{}, nil, nil, ErrInvalidHash
+	}
+	salt, err := encoding.DecodeString(parts[4])
+	if err != nil {
+		return params{}, nil, nil, ErrInvalidHash
+	}
+	key, err := encoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params{}, nil, nil, ErrInvalidHash
+	}
+	p.saltLength, p.keyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
+
+func decodeLegacy(hash []byte) (params, []byte, []byte, error) {
+	if len(hash) != legacySaltLength+legacyKeyLength {
+		return params{}, nil, nil, fmt.Errorf("%w: length %d", ErrInvalidHash, len(hash))
+	}
+	p := params{
+		memory:      legacyMemory,
+		iterations:  legacyIterations,
+		parallelism: legacyParallelism,
+		saltLength:  legacySaltLength,
+		keyLength:   legacyKeyLength,
+	}
+	return p, hash[:legacySaltLength], hash[legacySaltLength:], nil
+}
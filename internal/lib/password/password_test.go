@@ -0,0 +1,101 @@
+package password
+
+import (
+	"context"
+	"crypto/rand"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"strings"
+	"testing"
+)
+
+// small parameters keep the tests fast
+func testConfig() *config.PasswordHashConfig {
+	return &config.PasswordHashConfig{Memory: 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestHasher_HashVerify(t *testing.T) {
+	h := NewHasher(testConfig())
+	hash, err := h.Hash(context.Background(), "Secret0!")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(hash), "$argon2id$v=19$m=1024,t=2,p=1$"))
+
+	ok, rehash, err := h.Verify(context.Background(), "Secret0!", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, rehash)
+
+	ok, _, err = h.Verify(context.Background(), "Secret1!", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := h.Hash(context.Background(), "Secret0!")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "salts are random")
+}
+
+func TestHasher_RehashWeaker(t *testing.T) {
+	weak := NewHasher(testConfig())
+	hash, err := weak.Hash(context.Background(), "Secret0!")
+	require.NoError(t, err)
+
+	conf := testConfig()
+	conf.Iterations = 3
+	ok, rehash, err := NewHasher(conf).Verify(context.Background(), "Secret0!", hash)
+	require.NoError(t, err)
+	assert.True(t, ok, "hashes verify with the parameters they record")
+	assert.True(t, rehash)
+
+	conf = testConfig()
+	conf.Memory = 512
+	ok, rehash, err = NewHasher(conf).Verify(context.Background(), "Secret0!", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, rehash, "stronger hashes are kept")
+}
+
+func TestHasher_Legacy(t *testing.T) {
+	salt := make([]byte, legacySaltLength)
+	_, err := rand.Read(salt)
+	require.NoError(t, err)
+	legacy := append(salt, argon2.IDKey([]byte("Secret0!"), salt, 1, 64*1024, 4, 32)...)
+
+	h := NewHasher(testConfig())
+	ok, rehash, err := h.Verify(context.Background(), "Secret0!", legacy)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, rehash, "legacy hashes are always replaced")
+
+	ok, rehash, err = h.Verify(context.Background(), "Secret1!", legacy)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, rehash)
+}
+
+func TestHasher_Invalid(t *testing.T) {
+	h := NewHasher(testConfig())
+
+	ok, _, err := h.Verify(context.Background(), "Secret0!", nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "an empty hash matches nothing")
+
+	tests := []struct {
+		name string
+		hash string
+		want error
+	}{
+		{name: "short blob", hash: "0123456789", want: ErrInvalidHash},
+		{name: "missing key", hash: "$argon2id$v=19$m=1024,t=2,p=1$c2FsdHNhbHQ", want: ErrInvalidHash},
+		{name: "bad params", hash: "$argon2id$v=19$m=x,t=2,p=1$c2FsdHNhbHQ$a2V5", want: ErrInvalidHash},
+		{name: "bad base64", hash: "$argon2id$v=19$m=1024,t=2,p=1$!!$a2V5", want: ErrInvalidHash},
+		{name: "other version", hash: "$argon2id$v=16$m=1024,t=2,p=1$c2FsdHNhbHQ$a2V5", want: ErrIncompatibleVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := h.Verify(context.Background(), "Secret0!", []byte(tt.hash))
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
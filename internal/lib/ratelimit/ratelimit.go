@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limiter allows at most limit calls per key within a sliding window. When a
+// call is refused it returns how long until the next one is allowed.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
+// sweepInterval is how often Memory drops the keys without calls in their
+// window.
+const sweepInterval = time.Minute
+
+// Memory is a Limiter local to the process.
+type Memory struct {
+	mu        sync.Mutex
+	calls     map[string]*callLog
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type callLog struct {
+	times  []time.Time
+	window time.Duration
+}
+
+func NewMemory() *Memory {
+	return &Memory{calls: make(map[string]*callLog), now: time.Now}
+}
+
+func (m *Memory) Allow(_ context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	log, ok := m.calls[key]
+	if !ok {
+		log = &callLog{}
+		m.calls[key] = log
+	}
+	log.window = window
+	log.expire(now)
+	if len(log.times) >= limit {
+		return false, log.times[0].Add(window).Sub(now), nil
+	}
+	log.times = append(log.times, now)
+	return true, 0, nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for key, log := range m.calls {
+		if log.expire(now); len(log.times) == 0 {
+			delete(m.calls, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+// expire drops the calls that left the window.
+func (l *callLog) expire(now time.Time) {
+	i := 0
+	for i < len(l.times) && !l.times[i].After(now.Add(-l.window)) {
+		i++
+	}
+	l.times = l.times[i:]
+}
+
+// Fallback asks primary and, while it fails, fallback. Limits of a shared
+// primary are global, the ones of a local fallback only per instance: the
+// service stays protected, if less strictly, while e.g. Redis is down.
+// Outages are logged once when they start and once when they end.
+type Fallback struct {
+	primary  Limiter
+	fallback Limiter
+	logger   *logger.Logger
+	degraded atomic.Bool
+}
+
+func NewFallback(primary, fallback Limiter, logger *logger.Logger) *Fallback {
+	return &Fallback{primary: primary, fallback: fallback, logger: logger}
+}
+
+func (f *Fallback) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	allowed, retryAfter, err := f.primary.Allow(ctx, key, limit, window)
+	if err == nil {
+		if f.degraded.CompareAndSwap(true, false) {
+			f.logger.InfoContext(ctx, "rate limiter recovered")
+		}
+		return allowed, retryAfter, nil
+	}
+	if f.degraded.CompareAndSwap(false, true) {
+		f.logger.WarnContext(ctx, "rate limiter unavailable, limiting locally", f.logger.String("error", err.Error()))
+	}
+	return f.fallback.Allow(ctx, key, limit, window)
+}
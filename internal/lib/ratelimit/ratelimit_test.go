@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemory_SlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := m.Allow(ctx, "k", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		now = now.Add(10 * time.Second)
+	}
+	allowed, retryAfter, err := m.Allow(ctx, "k", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	allowed, _, err = m.Allow(ctx, "other", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed, "keys are limited independently")
+
+	// the first call leaves the window
+	now = now.Add(retryAfter)
+	allowed, _, err = m.Allow(ctx, "k", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _, err = m.Allow(ctx, "k", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
+
+func TestMemory_SweepsIdleKeys(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	_, _, err := m.Allow(ctx, "idle", 1, time.Second)
+	require.NoError(t, err)
+	now = now.Add(sweepInterval)
+	_, _, err = m.Allow(ctx, "busy", 1, time.Second)
+	require.NoError(t, err)
+	assert.NotContains(t, m.calls, "idle")
+	assert.Contains(t, m.calls, "busy")
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, int, time.Duration) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func TestFallback(t *testing.T) {
+	ctx := context.Background()
+	log := &logger.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+	primary := NewMemory()
+	f := NewFallback(primary, NewMemory(), log)
+	allowed, _, err := f.Allow(ctx, "k", 1, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Contains(t, primary.calls, "k")
+
+	f = NewFallback(failingLimiter{}, NewMemory(), log)
+	allowed, _, err = f.Allow(ctx, "k", 1, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, retryAfter, err := f.Allow(ctx, "k", 1, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Positive(t, retryAfter)
+}
+
+// flakyLimiter fails while down is set.
+type flakyLimiter struct {
+	down bool
+}
+
+func (l *flakyLimiter) Allow(context.Context, string, int, time.Duration) (bool, time.Duration, error) {
+	if l.down {
+		return false, 0, errors.New("connection refused")
+	}
+	return true, 0, nil
+}
+
+func TestFallback_LogsOncePerOutage(t *testing.T) {
+	ctx := context.Background()
+	var out bytes.Buffer
+	log := &logger.Logger{Logger: slog.New(slog.NewTextHandler(&out, nil))}
+	primary := &flakyLimiter{down: true}
+	f := NewFallback(primary, NewMemory(), log)
+
+	for i := 0; i < 3; i++ {
+		_, _, err := f.Allow(ctx, "k", 10, time.Minute)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, strings.Count(out.String(), "rate limiter unavailable"))
+
+	primary.down = false
+	for i := 0; i < 3; i++ {
+		_, _, err := f.Allow(ctx, "k", 10, time.Minute)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, strings.Count(out.String(), "rate limiter recovered"))
+
+	primary.down = true
+	_, _, err := f.Allow(ctx, "k", 10, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(out.String(), "rate limiter unavailable"))
+}
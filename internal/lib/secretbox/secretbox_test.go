@@ -0,0 +1,48 @@
+package secretbox
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestBox_SealOpen(t *testing.T) {
+	box, err := New(newKey(t))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "JBSWY3DPEHPK3PXP")
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", string(opened))
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = box.Open(sealed)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	other, err := New(newKey(t))
+	require.NoError(t, err)
+	sealed, err = box.Seal([]byte("secret"))
+	require.NoError(t, err)
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}
+
+func TestNew_InvalidKey(t *testing.T) {
+	_, err := New(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+	_, err = New("not base64!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package totp
+
+import (
+	"encoding/base32"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B, SHA1 vectors truncated to 6 digits.
+func TestCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range vectors {
+		got, err := Code(secret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "time %d", unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+
+	code, err := Code(secret, Step(now))
+	require.NoError(t, err)
+	step, ok := Validate(secret, code, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	previous, err := Code(secret, Step(now)-1)
+	require.NoError(t, err)
+	step, ok = Validate(secret, previous, now, 1)
+	assert.True(t, ok, "one step of drift is tolerated")
+	assert.Equal(t, Step(now)-1, step)
+
+	_, ok = Validate(secret, previous, now, 0)
+	assert.False(t, ok)
+
+	old, err := Code(secret, Step(now)-3)
+	require.NoError(t, err)
+	_, ok = Validate(secret, old, now, 1)
+	assert.False(t, ok)
+
+	_, ok = Validate(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestURL(t *testing.T) {
+	u := URL("auth-service", "user@example.com", "ABC")
+	assert.True(t, strings.HasPrefix(u, "otpauth://totp/auth-service:user@example.com?"))
+	assert.Contains(t, u, "secret=ABC")
+	assert.Contains(t, u, "issuer=auth-service")
+}
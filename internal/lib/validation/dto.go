@@ -17,12 +17,95 @@ type UpdateUserPasswordRequest struct {
 	NewPasswordConfirm string `validate:"required,eqfield=NewPassword"`
 }
 
+type DeactivateUserRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Reason string `validate:"max=500"`
+}
+
+type ValidatePasswordRequest struct {
+	UserID   string `json:"user_id" validate:"required,uuid"`
+	Password string `validate:"required,max=128"`
+}
+
 type UpdateUserEmailRequest struct {
 	ID    string `validate:"required,uuid7"`
 	Email string `validate:"required,email,min=5,max=255"`
 }
 
+type ActionTokenRequest struct {
+	Token string `validate:"required,max=4096"`
+}
+
+type RequestPasswordResetRequest struct {
+	Email string `validate:"required,email,min=5,max=255"`
+}
+
+type ResetPasswordRequest struct {
+	Token              string `validate:"required,max=4096"`
+	NewPassword        string `validate:"required,strongPassword"`
+	NewPasswordConfirm string `validate:"required,eqfield=NewPassword"`
+}
+
+type MFACodeRequest struct {
+	Code string `validate:"required,max=32"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required,max=4096"`
+	Code     string `validate:"required,max=32"`
+	DeviceRequest
+}
+
+type PasskeyCredentialRequest struct {
+	CeremonyID string `json:"ceremony_id" validate:"required,uuid"`
+	Credential string `validate:"required,max=65536"`
+	DeviceRequest
+}
+
+type StartPasswordlessLoginRequest struct {
+	Email string `validate:"required,email,min=5,max=255"`
+}
+
+// CompletePasswordlessLoginRequest takes either the token of a link or the
+// email and code.
+type CompletePasswordlessLoginRequest struct {
+	Token string `validate:"required_without=Code,max=4096"`
+	Email string `validate:"required_with=Code,omitempty,email,max=255"`
+	Code  string `validate:"required_without=Token,omitempty,numeric,len=6"`
+	DeviceRequest
+}
+
 type LoginRequest struct {
 	Email    string `validate:"required,email,min=5,max=255"`
 	Password string `validate:"required,strongPassword"`
+	DeviceRequest
+}
+
+type RegisterRequest struct {
+	CreateUserRequest
+	DeviceRequest
+}
+
+type DeviceRequest struct {
+	DeviceID   string `json:"device_id" validate:"max=128"`
+	DeviceName string `json:"device_name" validate:"max=100"`
+}
+
+type SessionRequest struct {
+	SessionID string `json:"session_id" validate:"required,uuid"`
+}
+
+type ListSessionsRequest struct {
+	UserID   string `json:"user_id" validate:"required,uuid"`
+	PageSize int32  `json:"page_size" validate:"min=0,max=100"`
+}
+
+type RevokeAllSessionsRequest struct {
+	UserID          string `json:"user_id" validate:"required,uuid"`
+	ExceptSessionID string `json:"except_session_id" validate:"omitempty,uuid"`
+}
+
+type RoleRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Role   string `validate:"required,max=64"`
 }
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// consumeScript deletes the pending token only if it is the expected one, so
+// a token can be consumed exactly once.
+var consumeScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ActionTokenRedisRepo tracks the pending action token of every user and
+// purpose. Only the most recently issued token is pending, saving a new one
+// invalidates the previous.
+type ActionTokenRedisRepo struct {
+	client *redis.Client
+}
+
+func NewActionTokenRedisRepo(client *redis.Client) *ActionTokenRedisRepo {
+	return &ActionTokenRedisRepo{client: client}
+}
+
+func (r *ActionTokenRedisRepo) Save(ctx context.Context, purpose, userID, tokenID string, ttl time.Duration) error {
+	const op = "repository.ActionTokenRedisRepo.Save"
+	if err := r.client.Set(ctx, actionTokenKey(purpose, userID), tokenID, ttl).Err(); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+// Consume marks the token as used. It returns domain.ErrActionTokenUsed when
+// the token was already consumed, superseded or expired.
+func (r *ActionTokenRedisRepo) Consume(ctx context.Context, purpose, userID, tokenID string) error {
+	const op = "repository.ActionTokenRedisRepo.Consume"
+	deleted, err := consumeScript.Run(ctx, r.client, []string{actionTokenKey(purpose, userID)}, tokenID).Int()
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if deleted == 0 {
+		return domain.ErrActionTokenUsed
+	}
+	return nil
+}
+
+func actionTokenKey(purpose, userID string) string {
+	return "action_token:" + purpose + ":" + userID
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// AttemptRedisRepo counts attempts per key in fixed windows. The window
+// starts with the first attempt and the counter disappears with it.
+type AttemptRedisRepo struct {
+	client *redis.Client
+}
+
+func NewAttemptRedisRepo(client *redis.Client) *AttemptRedisRepo {
+	return &AttemptRedisRepo{client: client}
+}
+
+// Hit records an attempt and returns the number of attempts in the current
+// window.
+func (r *AttemptRedisRepo) Hit(ctx context.Context, key string, window time.Duration) (int, error) {
+	const op = "repository.AttemptRedisRepo.Hit"
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, attemptsKey(key))
+	pipe.ExpireNX(ctx, attemptsKey(key), window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s, %w", op, err)
+	}
+	return int(incr.Val()), nil
+}
+
+// Count returns the number of attempts in the current window and how long
+// the window still lasts.
+func (r *AttemptRedisRepo) Count(ctx context.Context, key string) (int, time.Duration, error) {
+	const op = "repository.AttemptRedisRepo.Count"
+	pipe := r.client.Pipeline()
+	get := pipe.Get(ctx, attemptsKey(key))
+	ttl := pipe.PTTL(ctx, attemptsKey(key))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return 0, 0, fmt.Errorf("%s, %w", op, err)
+	}
+	count, err := get.Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s, %w", op, err)
+	}
+	return count, max(ttl.Val(), 0), nil
+}
+
+func (r *AttemptRedisRepo) Reset(ctx context.Context, key string) error {
+	const op = "repository.AttemptRedisRepo.Reset"
+	if err := r.client.Del(ctx, attemptsKey(key)).Err(); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+func attemptsKey(key string) string {
+	return "attempts:" + key
+}
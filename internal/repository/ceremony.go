@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// CeremonyRedisRepo keeps the server side state of begun passkey
+// ceremonies until they are finished or expire.
+type CeremonyRedisRepo struct {
+	client *redis.Client
+}
+
+func NewCeremonyRedisRepo(client *redis.Client) *CeremonyRedisRepo {
+	return &CeremonyRedisRepo{client: client}
+}
+
+func (r *CeremonyRedisRepo) Save(ctx context.Context, ceremonyID string, state []byte, ttl time.Duration) error {
+	const op = "repository.CeremonyRedisRepo.Save"
+	if err := r.client.Set(ctx, ceremonyKey(ceremonyID), state, ttl).Err(); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+// Take deletes the ceremony and returns its state, so every challenge is
+// answered at most once. Unknown and expired ceremonies yield
+// domain.ErrCeremonyNotFound.
+func (r *CeremonyRedisRepo) Take(ctx context.Context, ceremonyID string) ([]byte, error) {
+	const op = "repository.CeremonyRedisRepo.Take"
+	state, err := r.client.GetDel(ctx, ceremonyKey(ceremonyID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrCeremonyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s, %w", op, err)
+	}
+	return state, nil
+}
+
+func ceremonyKey(ceremonyID string) string {
+	return "webauthn_ceremony:" + ceremonyID
+}
@@ -10,8 +10,18 @@ import (
 
 // TODO refactor
 type Container struct {
-	UserRepo    *UserPgeRepo
-	SessionRepo *SessionRedisRepo
+	UserRepo         *UserPgeRepo
+	RoleRepo         *RolePgRepo
+	MFARepo          *MFAPgRepo
+	PasskeyRepo      *PasskeyPgRepo
+	SessionRepo      *SessionRedisRepo
+	AttemptRepo      *AttemptRedisRepo
+	TokenRepo        *ActionTokenRedisRepo
+	ResetRepo        *PasswordResetRedisRepo
+	CeremonyRepo     *CeremonyRedisRepo
+	PasswordlessRepo *PasswordlessRedisRepo
+	LoginBlockRepo   *LoginBlockRedisRepo
+	RateLimitRepo    *RateLimitRedisRepo
 }
 
 func NewContainer(
@@ -20,19 +30,49 @@ func NewContainer(
 	logger *logger.Logger,
 ) *Container {
 	var (
-		userRepo    *UserPgeRepo
-		sessionRepo *SessionRedisRepo
+		userRepo         *UserPgeRepo
+		roleRepo         *RolePgRepo
+		mfaRepo          *MFAPgRepo
+		passkeyRepo      *PasskeyPgRepo
+		sessionRepo      *SessionRedisRepo
+		attemptRepo      *AttemptRedisRepo
+		tokenRepo        *ActionTokenRedisRepo
+		resetRepo        *PasswordResetRedisRepo
+		ceremonyRepo     *CeremonyRedisRepo
+		passwordlessRepo *PasswordlessRedisRepo
+		loginBlockRepo   *LoginBlockRedisRepo
+		rateLimitRepo    *RateLimitRedisRepo
 	)
 	if db, ok := storage.SQL().(*pgxpool.Pool); ok {
 		userRepo = NewPgUserRepository(db)
+		roleRepo = NewPgRoleRepository(db)
+		mfaRepo = NewPgMFARepository(db)
+		passkeyRepo = NewPgPasskeyRepository(db)
 	}
 
 	if cache, ok := storage.Cache().(*redis.Client); ok {
 		sessionRepo = NewSessionRedisRepo(cache, cfg.Redis.TTL)
+		attemptRepo = NewAttemptRedisRepo(cache)
+		tokenRepo = NewActionTokenRedisRepo(cache)
+		resetRepo = NewPasswordResetRedisRepo(cache)
+		ceremonyRepo = NewCeremonyRedisRepo(cache)
+		passwordlessRepo = NewPasswordlessRedisRepo(cache)
+		loginBlockRepo = NewLoginBlockRedisRepo(cache)
+		rateLimitRepo = NewRateLimitRedisRepo(cache)
 	}
 
 	return &Container{
-		UserRepo:    userRepo,
-		SessionRepo: sessionRepo,
+		UserRepo:         userRepo,
+		RoleRepo:         roleRepo,
+		MFARepo:          mfaRepo,
+		PasskeyRepo:      passkeyRepo,
+		SessionRepo:      sessionRepo,
+		AttemptRepo:      attemptRepo,
+		TokenRepo:        tokenRepo,
+		ResetRepo:        resetRepo,
+		CeremonyRepo:     ceremonyRepo,
+		PasswordlessRepo: passwordlessRepo,
+		LoginBlockRepo:   loginBlockRepo,
+		RateLimitRepo:    rateLimitRepo,
 	}
 }
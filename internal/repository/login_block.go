@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// LoginBlockRedisRepo keeps the blocks put on logins after failed attempts.
+// A block disappears when it expires.
+type LoginBlockRedisRepo struct {
+	client *redis.Client
+}
+
+func NewLoginBlockRedisRepo(client *redis.Client) *LoginBlockRedisRepo {
+	return &LoginBlockRedisRepo{client: client}
+}
+
+// Block refuses logins for key during d, replacing an earlier block.
+func (r *LoginBlockRedisRepo) Block(ctx context.Context, key, reason string, d time.Duration) error {
+	const op = "repository.LoginBlockRedisRepo.Block"
+	if err := r.client.Set(ctx, loginBlockKey(key), reason, d).Err(); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+// Blocked returns the reason of the block on key and how long it still
+// lasts. The reason is empty when key is not blocked.
+func (r *LoginBlockRedisRepo) Blocked(ctx context.Context, key string) (string, time.Duration, error) {
+	const op = "repository.LoginBlockRedisRepo.Blocked"
+	pipe := r.client.Pipeline()
+	get := pipe.Get(ctx, loginBlockKey(key))
+	ttl := pipe.PTTL(ctx, loginBlockKey(key))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return "", 0, fmt.Errorf("%s, %w", op, err)
+	}
+	reason, err := get.Result()
+	if errors.Is(err, redis.Nil) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("%s, %w", op, err)
+	}
+	return reason, max(ttl.Val(), 0), nil
+}
+
+func (r *LoginBlockRedisRepo) Unblock(ctx context.Context, key string) error {
+	const op = "repository.LoginBlockRedisRepo.Unblock"
+	if err := r.client.Del(ctx, loginBlockKey(key)).Err(); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+func loginBlockKey(key string) string {
+	return "login_block:" + key
+}
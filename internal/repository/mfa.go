@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type MFAPgRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgMFARepository(db *pgxpool.Pool) *MFAPgRepo {
+	return &MFAPgRepo{db: db}
+}
+
+func (r *MFAPgRepo) GetTOTP(ctx context.Context, userID string) (*models.TOTP, error) {
+	const op = "repository.MFAPgRepo.GetTOTP"
+	var t models.TOTP
+	query := `SELECT user_id, secret, enabled_at, last_used_step, created_at FROM user_totp WHERE user_id = $1`
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&t.UserID,
+		&t.Secret,
+		&t.EnabledAt,
+		&t.LastUsedStep,
+		&t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrMFANotEnrolled
+		}
+		return nil, fmt.Errorf("%s, %w", op, err)
+	}
+	return &t, nil
+}
+
+// SaveTOTP starts a new enrolment, replacing an unconfirmed one. Enabled
+// enrolments are left untouched and reported as domain.ErrMFAAlreadyEnabled.
+func (r *MFAPgRepo) SaveTOTP(ctx context.Context, t *models.TOTP) error {
+	const op = "repository.MFAPgRepo.SaveTOTP"
+	query := `INSERT INTO user_totp (user_id, secret, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_used_step = 0
+		WHERE user_totp.enabled_at IS NULL`
+	res, err := r.db.Exec(ctx, query, t.UserID, t.Secret, t.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return domain.ErrUserNotFound
+		}
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableTOTP turns the enrolment on and replaces the recovery codes.
+func (r *MFAPgRepo) EnableTOTP(ctx context.Context, userID string, enabledAt time.Time, codeHashes []string) error {
+	const op = "repository.MFAPgRepo.EnableTOTP"
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		res, err := tx.Exec(ctx, `UPDATE user_totp SET enabled_at = $2 WHERE user_id = $1 AND enabled_at IS NULL`, userID, enabledAt)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return domain.ErrMFAAlreadyEnabled
+		}
+		return replaceRecoveryCodes(ctx, tx, userID, codeHashes)
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrMFAAlreadyEnabled) {
+			return err
+		}
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+func (r *MFAPgRepo) DeleteTOTP(ctx context.Context, userID string) error {
+	const op = "repository.MFAPgRepo.DeleteTOTP"
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		res, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return domain.ErrMFANotEnrolled
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrMFANotEnrolled) {
+			return err
+		}
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+// UseStep records the time step of an accepted code. A step that is not
+// newer than the last recorded one is a replay and yields
+// domain.ErrInvalidMFACode.
+func (r *MFAPgRepo) UseStep(ctx context.Context, userID string, step int64) error {
+	const op = "repository.MFAPgRepo.UseStep"
+	query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+	res, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// UseRecoveryCode consumes an unused recovery code.
+func (r *MFAPgRepo) UseRecoveryCode(ctx context.Context, userID, codeHash string) error {
+	const op = "repository.MFAPgRepo.UseRecoveryCode"
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	res, err := r.db.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	rows := make([][]any, len(codeHashes))
+	for i, hash := range codeHashes {
+		rows[i] = []any{userID, hash}
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"mfa_recovery_codes"}, []string{"user_id", "code_hash"}, pgx.CopyFromRows(rows))
+	return err
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+const passkeyColumns = `id, user_id, public_key, attestation_type, aaguid, sign_count, transports,
+	backup_eligible, backup_state, created_at, last_used_at`
+
+type PasskeyPgRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgPasskeyRepository(db *pgxpool.Pool) *PasskeyPgRepo {
+	return &PasskeyPgRepo{db: db}
+}
+
+func (r *PasskeyPgRepo) Create(ctx context.Context, p *models.Passkey) error {
+	const op = "repository.PasskeyPgRepo.Create"
+	query := `INSERT INTO webauthn_credentials (` + passkeyColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := r.db.Exec(ctx, query,
+		p.ID,
+		p.UserID,
+		p.PublicKey,
+		p.AttestationType,
+		p.AAGUID,
+		int64(p.SignCount),
+		p.Transports,
+		p.BackupEligible,
+		p.BackupState,
+		p.CreatedAt,
+		p.LastUsedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return domain.ErrPasskeyExists
+			case "23503":
+				return domain.ErrUserNotFound
+			}
+		}
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+func (r *PasskeyPgRepo) GetByID(ctx context.Context, id []byte) (*models.Passkey, error) {
+	const op = "repository.PasskeyPgRepo.GetByID"
+	query := `SELECT ` + passkeyColumns + ` FROM webauthn_credentials WHERE id = $1`
+	p, err := scanPasskey(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrPasskeyNotFound
+		}
+		return nil, fmt.Errorf("%s, %w", op, err)
+	}
+	return p, nil
+}
+
+func (r *PasskeyPgRepo) ListByUser(ctx context.Context, userID string) ([]*models.Passkey, error) {
+	const op = "repository.PasskeyPgRepo.ListByUser"
+	query := `SELECT ` + passkeyColumns + ` FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %w", op, err)
+	}
+	defer rows.Close()
+
+	var passkeys []*models.Passkey
+	for rows.Next() {
+		p, err := scanPasskey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s, %w", op, err)
+		}
+		passkeys = append(passkeys, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s, %w", op, err)
+	}
+	return passkeys, nil
+}
+
+// UpdateUsage records a successful login with the passkey.
+func (r *PasskeyPgRepo) UpdateUsage(ctx context.Context, id []byte, signCount uint32, backupState bool, usedAt time.Time) error {
+	const op = "repository.PasskeyPgRepo.UpdateUsage"
+	query := `UPDATE webauthn_credentials SET sign_count = $2, backup_state = $3, last_used_at = $4 WHERE id = $1`
+	res, err := r.db.Exec(ctx, query, id, int64(signCount), backupState, usedAt)
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrPasskeyNotFound
+	}
+	return nil
+}
+
+func scanPasskey(row pgx.Row) (*models.Passkey, error) {
+	var (
+		p         models.Passkey
+		signCount int64
+	)
+	err := row.Scan(
+		&p.ID,
+		&p.UserID,
+		&p.PublicKey,
+		&p.AttestationType,
+		&p.AAGUID,
+		&signCount,
+		&p.Transports,
+		&p.BackupEligible,
+		&p.BackupState,
+		&p.CreatedAt,
+		&p.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.SignCount = uint32(signCount)
+	return &p, nil
+}
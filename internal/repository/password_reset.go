@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// PasswordResetRedisRepo stores password reset tokens by their hash, the
+// token itself is never persisted. A user has at most one pending token,
+// saving a new one drops the previous.
+type PasswordResetRedisRepo struct {
+	client *redis.Client
+}
+
+func NewPasswordResetRedisRepo(client *redis.Client) *PasswordResetRedisRepo {
+	return &PasswordResetRedisRepo{client: client}
+}
+
+func (r *PasswordResetRedisRepo) Save(ctx context.Context, userID, tokenHash string, ttl time.Duration) error {
+	const op = "repository.PasswordResetRedisRepo.Save"
+	previous, err := r.client.Get(ctx, passwordResetUserKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+
+	pipe := r.client.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, passwordResetKey(previous))
+	}
+	pipe.Set(ctx, passwordResetKey(tokenHash), userID, ttl)
+	pipe.Set(ctx, passwordResetUserKey(userID), tokenHash, ttl)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+// Consume deletes the token and returns the user it was issued to. Unknown,
+// expired and already used tokens yield domain.ErrActionTokenUsed.
+func (r *PasswordResetRedisRepo) Consume(ctx context.Context, tokenHash string) (string, error) {
+	const op = "repository.PasswordResetRedisRepo.Consume"
+	userID, err := r.client.GetDel(ctx, passwordResetKey(tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", domain.ErrActionTokenUsed
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s, %w", op, err)
+	}
+	if err = r.client.Del(ctx, passwordResetUserKey(userID)).Err(); err != nil {
+		return "", fmt.Errorf("%s, %w", op, err)
+	}
+	return userID, nil
+}
+
+func passwordResetKey(tokenHash string) string {
+	return "password_reset:" + tokenHash
+}
+
+func passwordResetUserKey(userID string) string {
+	return "password_reset_user:" + userID
+}
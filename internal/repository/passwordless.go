@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// PasswordlessRedisRepo stores the secrets of passwordless logins by hash.
+// An address has at most one pending link or code, issuing a new one
+// invalidates the previous. Addresses are keyed by their hash as well.
+type PasswordlessRedisRepo struct {
+	client *redis.Client
+}
+
+func NewPasswordlessRedisRepo(client *redis.Client) *PasswordlessRedisRepo {
+	return &PasswordlessRedisRepo{client: client}
+}
+
+func (r *PasswordlessRedisRepo) SaveLink(ctx context.Context, emailHash, email, tokenHash string, ttl time.Duration) error {
+	const op = "repository.PasswordlessRedisRepo.SaveLink"
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, passwordlessPendingKey(emailHash), tokenHash, ttl)
+	pipe.Set(ctx, passwordlessLinkKey(tokenHash), email, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+func (r *PasswordlessRedisRepo) SaveCode(ctx context.Context, emailHash, codeHash string, ttl time.Duration) error {
+	const op = "repository.PasswordlessRedisRepo.SaveCode"
+	if err := r.client.Set(ctx, passwordlessPendingKey(emailHash), codeHash, ttl).Err(); err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+// TakeLink deletes the link and returns the address it was sent to. The
+// link is only valid if ConsumePending succeeds for it as well, it may have
+// been superseded. Unknown and expired links yield domain.ErrActionTokenUsed.
+func (r *PasswordlessRedisRepo) TakeLink(ctx context.Context, tokenHash string) (string, error) {
+	const op = "repository.PasswordlessRedisRepo.TakeLink"
+	email, err := r.client.GetDel(ctx, passwordlessLinkKey(tokenHash)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", domain.ErrActionTokenUsed
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s, %w", op, err)
+	}
+	return email, nil
+}
+
+// ConsumePending deletes the pending link or code of the address if it is
+// the given one, otherwise it yields domain.ErrActionTokenUsed.
+func (r *PasswordlessRedisRepo) ConsumePending(ctx context.Context, emailHash, secretHash string) error {
+	const op = "repository.PasswordlessRedisRepo.ConsumePending"
+	deleted, err := consumeScript.Run(ctx, r.client, []string{passwordlessPendingKey(emailHash)}, secretHash).Int()
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if deleted == 0 {
+		return domain.ErrActionTokenUsed
+	}
+	return nil
+}
+
+func passwordlessPendingKey(emailHash string) string {
+	return "passwordless:pending:" + emailHash
+}
+
+func passwordlessLinkKey(tokenHash string) string {
+	return "passwordless:link:" + tokenHash
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// slidingWindowScript keeps the calls of the last window in a sorted set
+// scored by time. A call is recorded only while fewer than limit calls are
+// in the window, otherwise the time until the oldest one leaves the window
+// is returned. The clock is the one of Redis, so all instances agree.
+var slidingWindowScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local window = tonumber(ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) < tonumber(ARGV[1]) then
+	redis.call("ZADD", KEYS[1], now, ARGV[3])
+	redis.call("PEXPIRE", KEYS[1], math.ceil(window / 1000))
+	return 0
+end
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return math.max(tonumber(oldest[2]) + window - now, 1)
+`)
+
+// RateLimitRedisRepo limits calls per key with a sliding window log shared
+// by all instances of the service.
+type RateLimitRedisRepo struct {
+	client *redis.Client
+}
+
+func NewRateLimitRedisRepo(client *redis.Client) *RateLimitRedisRepo {
+	return &RateLimitRedisRepo{client: client}
+}
+
+// Allow records a call for key when fewer than limit calls were recorded
+// within window. Refused calls are not recorded, the returned duration is
+// how long until the next call is allowed.
+func (r *RateLimitRedisRepo) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	const op = "repository.RateLimitRedisRepo.Allow"
+	wait, err := slidingWindowScript.Run(ctx, r.client, []string{rateLimitKey(key)},
+		limit, window.Microseconds(), uuid.NewString(),
+	).Int64()
+	if err != nil {
+		return false, 0, fmt.Errorf("%s, %w", op, err)
+	}
+	if wait <= 0 {
+		return true, 0, nil
+	}
+	return false, time.Duration(wait) * time.Microsecond, nil
+}
+
+func rateLimitKey(key string) string {
+	return "rate_limit:" + key
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RolePgRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgRoleRepository(db *pgxpool.Pool) *RolePgRepo {
+	return &RolePgRepo{db: db}
+}
+
+func (r *RolePgRepo) GetUserAccess(ctx context.Context, userID string) (*models.UserAccess, error) {
+	const op = "repository.RolePgRepo.GetUserAccess"
+	query := `SELECT r.name, COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = $1
+		GROUP BY r.name
+		ORDER BY r.name`
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s, %w", op, err)
+	}
+	defer rows.Close()
+
+	access := &models.UserAccess{}
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var (
+			role        string
+			permissions []string
+		)
+		if err = rows.Scan(&role, &permissions); err != nil {
+			return nil, fmt.Errorf("%s, %w", op, err)
+		}
+		access.Roles = append(access.Roles, role)
+		for _, p := range permissions {
+			if !seen[p] {
+				seen[p] = true
+				access.Permissions = append(access.Permissions, p)
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s, %w", op, err)
+	}
+	return access, nil
+}
+
+func (r *RolePgRepo) AssignRole(ctx context.Context, userID, role string) error {
+	const op = "repository.RolePgRepo.AssignRole"
+	var roleID int
+	err := r.db.QueryRow(ctx, `SELECT id FROM roles WHERE name = $1`, role).Scan(&roleID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrRoleNotFound
+		}
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	query := `INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err = r.db.Exec(ctx, query, userID, roleID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return domain.ErrUserNotFound
+		}
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	return nil
+}
+
+func (r *RolePgRepo) RevokeRole(ctx context.Context, userID, role string) error {
+	const op = "repository.RolePgRepo.RevokeRole"
+	query := `DELETE FROM user_roles ur USING roles r
+		WHERE ur.role_id = r.id AND ur.user_id = $1 AND r.name = $2`
+	res, err := r.db.Exec(ctx, query, userID, role)
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrRoleNotAssigned
+	}
+	return nil
+}
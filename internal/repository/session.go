@@ -11,6 +11,10 @@ import (
 	"time"
 )
 
+// activeSessionsKey indexes every session by its expiry, so they can be
+// counted without scanning the keyspace.
+const activeSessionsKey = "active_sessions"
+
 type SessionRedisRepo struct {
 	client     *redis.Client
 	expiration time.Duration
@@ -31,21 +35,21 @@ func (r *SessionRedisRepo) Create(ctx context.Context, session *models.Session)
 		return domain.ErrSessionAlreadyExists
 	}
 
-	sessionData := map[string]interface{}{
-		"user_id":            session.UserID,
-		"access_token":       session.AccessToken,
-		"refresh_token":      session.RefreshToken,
-		"user_agent":         session.UserAgent,
-		"ip_address":         session.IpAddress,
-		"created_at":         session.CreatedAt.Unix(),
-		"expires_at":         session.ExpiresAt.Unix(),
-		"refresh_expires_at": session.RefreshExpiresAt.Unix(),
-		//"is_revoked":         session.IsRevoked,
-	}
+	sessionData := marshalSession(session)
+	indexKey := userSessionsKey(session.UserID)
 	pipe := r.client.Pipeline()
 	pipe.HSet(ctx, "session:"+session.ID, sessionData)
 	//pipe.HSet(ctx, "session:"+session.ID, "last_activity", time.Now().Unix())
 	pipe.Expire(ctx, "session:"+session.ID, r.expiration)
+	pipe.ZAdd(ctx, indexKey, redis.Z{
+		Score:  float64(time.Now().Add(r.expiration).Unix()),
+		Member: session.ID,
+	})
+	pipe.Expire(ctx, indexKey, r.expiration)
+	pipe.ZAdd(ctx, activeSessionsKey, redis.Z{
+		Score:  float64(time.Now().Add(r.expiration).Unix()),
+		Member: session.ID,
+	})
 
 	_, err = pipe.Exec(ctx)
 	if err != nil {
@@ -81,17 +85,7 @@ func (r *SessionRedisRepo) Update(ctx context.Context, session *models.Session)
 		return domain.ErrSessionExpired
 	}
 
-	sessionData := map[string]interface{}{
-		"user_id":            session.UserID,
-		"access_token":       session.AccessToken,
-		"refresh_token":      session.RefreshToken,
-		"user_agent":         session.UserAgent,
-		"ip_address":         session.IpAddress,
-		"created_at":         session.CreatedAt.Unix(),
-		"expires_at":         session.ExpiresAt.Unix(),
-		"refresh_expires_at": session.RefreshExpiresAt.Unix(),
-		//"is_revoked":         session.IsRevoked,
-	}
+	sessionData := marshalSession(session)
 	_, err = r.client.HSet(ctx, "session:"+session.ID, sessionData).Result()
 	if err != nil {
 		return fmt.Errorf("%s, %w", op, err)
@@ -99,6 +93,43 @@ func (r *SessionRedisRepo) Update(ctx context.Context, session *models.Session)
 	return nil
 }
 
+// Rotate stores the rotated session only if the refresh token in Redis is
+// still expectedRefreshToken. If a concurrent refresh got there first,
+// domain.ErrSessionRotated is returned and nothing is written.
+func (r *SessionRedisRepo) Rotate(ctx context.Context, session *models.Session, expectedRefreshToken string) error {
+	const op = "repository.SessionRedisRepo.Rotate"
+	key := "session:" + session.ID
+
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.HGet(ctx, key, "refresh_token").Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return domain.ErrSessionExpired
+			}
+			return err
+		}
+		if current != expectedRefreshToken {
+			return domain.ErrSessionRotated
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, marshalSession(session))
+			return nil
+		})
+		return err
+	}, key)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, redis.TxFailedErr):
+		return domain.ErrSessionRotated
+	case errors.Is(err, domain.ErrSessionExpired), errors.Is(err, domain.ErrSessionRotated):
+		return err
+	default:
+		return fmt.Errorf("%s, %w", op, err)
+	}
+}
+
 //func (r *SessionRedisRepo) Delete(ctx context.Context, sessionID string) error {
 //	data, res := r.client.Del(ctx, "session:"+sessionID).Result()
 //	if res != nil {
@@ -119,13 +150,140 @@ func (r *SessionRedisRepo) Revoke(ctx context.Context, sessionID string) error {
 	if !ok {
 		return domain.ErrSessionExpired
 	}
-	err = r.client.Del(ctx, "session:"+sessionID).Err()
-	if err != nil {
+	userID, err := r.client.HGet(ctx, "session:"+sessionID, "user_id").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	pipe := r.client.Pipeline()
+	pipe.Del(ctx, "session:"+sessionID)
+	if userID != "" {
+		pipe.ZRem(ctx, userSessionsKey(userID), sessionID)
+	}
+	pipe.ZRem(ctx, activeSessionsKey, sessionID)
+	if _, err = pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("%s, %w", op, err)
 	}
 	return nil
 }
 
+// ListByUser returns a page of the user's live sessions, newest first, and
+// the total number of live sessions. Index entries whose session has expired
+// are pruned on the way.
+func (r *SessionRedisRepo) ListByUser(ctx context.Context, userID string, offset, limit int) ([]*models.Session, int, error) {
+	const op = "repository.SessionRedisRepo.ListByUser"
+	indexKey := userSessionsKey(userID)
+
+	if err := r.pruneIndex(ctx, userID); err != nil {
+		return nil, 0, fmt.Errorf("%s, %w", op, err)
+	}
+	total, err := r.client.ZCard(ctx, indexKey).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s, %w", op, err)
+	}
+	ids, err := r.client.ZRevRange(ctx, indexKey, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s, %w", op, err)
+	}
+
+	sessions := make([]*models.Session, 0, len(ids))
+	for _, id := range ids {
+		ses, err := r.GetById(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrSessionNotFound) {
+				continue
+			}
+			return nil, 0, err
+		}
+		sessions = append(sessions, ses)
+	}
+	return sessions, int(total), nil
+}
+
+// RevokeAllByUser deletes every session of the user except exceptSessionID,
+// which may be empty, and returns the number of revoked sessions.
+func (r *SessionRedisRepo) RevokeAllByUser(ctx context.Context, userID, exceptSessionID string) (int, error) {
+	const op = "repository.SessionRedisRepo.RevokeAllByUser"
+	indexKey := userSessionsKey(userID)
+
+	ids, err := r.client.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("%s, %w", op, err)
+	}
+
+	pipe := r.client.Pipeline()
+	revoked := 0
+	for _, id := range ids {
+		if id == exceptSessionID {
+			continue
+		}
+		pipe.Del(ctx, "session:"+id)
+		pipe.ZRem(ctx, indexKey, id)
+		pipe.ZRem(ctx, activeSessionsKey, id)
+		revoked++
+	}
+	if revoked == 0 {
+		return 0, nil
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s, %w", op, err)
+	}
+	return revoked, nil
+}
+
+// pruneIndex drops index entries past their expiry score as well as entries
+// whose session hash is already gone.
+func (r *SessionRedisRepo) pruneIndex(ctx context.Context, userID string) error {
+	indexKey := userSessionsKey(userID)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := r.client.ZRemRangeByScore(ctx, indexKey, "-inf", "("+now).Err(); err != nil {
+		return err
+	}
+
+	ids, err := r.client.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	exists := make([]*redis.IntCmd, len(ids))
+	for i, id := range ids {
+		exists[i] = pipe.Exists(ctx, "session:"+id)
+	}
+	if _, err = pipe.Exec(ctx); err != nil {
+		return err
+	}
+	var stale []interface{}
+	for i, cmd := range exists {
+		if cmd.Val() == 0 {
+			stale = append(stale, ids[i])
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return r.client.ZRem(ctx, indexKey, stale...).Err()
+}
+
+// Count returns the number of live sessions, from the activeSessionsKey
+// index. Entries past their expiry are dropped first.
+func (r *SessionRedisRepo) Count(ctx context.Context) (int, error) {
+	const op = "repository.SessionRedisRepo.Count"
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	pipe := r.client.Pipeline()
+	pipe.ZRemRangeByScore(ctx, activeSessionsKey, "-inf", "("+now)
+	count := pipe.ZCard(ctx, activeSessionsKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("%s, %w", op, err)
+	}
+	return int(count.Val()), nil
+}
+
+func userSessionsKey(userID string) string {
+	return "user_sessions:" + userID
+}
+
 func (r *SessionRedisRepo) Exists(ctx context.Context, sessionID string) (bool, error) {
 	const op = "repository.SessionRedisRepo.Exists"
 	exists, err := r.client.Exists(ctx, "session:"+sessionID).Result()
@@ -135,6 +293,27 @@ func (r *SessionRedisRepo) Exists(ctx context.Context, sessionID string) (bool,
 	return exists > 0, nil
 }
 
+func marshalSession(session *models.Session) map[string]interface{} {
+	data := map[string]interface{}{
+		"user_id":                session.UserID,
+		"access_token":           session.AccessToken,
+		"refresh_token":          session.RefreshToken,
+		"previous_refresh_token": session.PreviousRefreshToken,
+		"user_agent":             session.UserAgent,
+		"ip_address":             session.IpAddress,
+		"device_id":              session.DeviceID,
+		"device_name":            session.DeviceName,
+		"created_at":             session.CreatedAt.Unix(),
+		"expires_at":             session.ExpiresAt.Unix(),
+		"refresh_expires_at":     session.RefreshExpiresAt.Unix(),
+		//"is_revoked":         session.IsRevoked,
+	}
+	if !session.RotatedAt.IsZero() {
+		data["rotated_at"] = session.RotatedAt.Unix()
+	}
+	return data
+}
+
 func (r *SessionRedisRepo) unmarshalSession(sessionID string, data map[string]string) (*models.Session, error) {
 	const op = "repository.SessionRedisRepo.unmarshalSession"
 	createdAt, err := strconv.ParseInt(data["created_at"], 10, 64)
@@ -149,20 +328,32 @@ func (r *SessionRedisRepo) unmarshalSession(sessionID string, data map[string]st
 	if err != nil {
 		return nil, fmt.Errorf("%s, %w", op, err)
 	}
+	var rotatedAt time.Time
+	if v, ok := data["rotated_at"]; ok {
+		ts, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s, %w", op, err)
+		}
+		rotatedAt = time.Unix(ts, 0)
+	}
 	//isRevoked, err := strconv.ParseBool(data["is_revoked"])
 	//if err != nil {
 	//	return nil, fmt.Errorf("failed to unmarshal session: %w", err)
 	//}
 	return &models.Session{
-		ID:               sessionID,
-		UserID:           data["user_id"],
-		AccessToken:      data["access_token"],
-		RefreshToken:     data["refresh_token"],
-		UserAgent:        data["user_agent"],
-		IpAddress:        data["ip_address"],
-		CreatedAt:        time.Unix(createdAt, 0),
-		ExpiresAt:        time.Unix(expiresAt, 0),
-		RefreshExpiresAt: time.Unix(refreshExpiresAt, 0),
+		ID:                   sessionID,
+		UserID:               data["user_id"],
+		AccessToken:          data["access_token"],
+		RefreshToken:         data["refresh_token"],
+		UserAgent:            data["user_agent"],
+		IpAddress:            data["ip_address"],
+		DeviceID:             data["device_id"],
+		DeviceName:           data["device_name"],
+		CreatedAt:            time.Unix(createdAt, 0),
+		ExpiresAt:            time.Unix(expiresAt, 0),
+		RefreshExpiresAt:     time.Unix(refreshExpiresAt, 0),
+		PreviousRefreshToken: data["previous_refresh_token"],
+		RotatedAt:            rotatedAt,
 		//IsRevoked:        isRevoked,
 	}, nil
 }
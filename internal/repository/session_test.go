@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSession_MarshalRoundTrip(t *testing.T) {
+	now := time.Unix(time.Now().Unix(), 0)
+	session := &models.Session{
+		ID:                   "session-id",
+		UserID:               "user-id",
+		AccessToken:          "access",
+		RefreshToken:         "refresh",
+		PreviousRefreshToken: "previous",
+		UserAgent:            "test-agent",
+		IpAddress:            "127.0.0.1",
+		DeviceID:             "device-id",
+		DeviceName:           "Pixel 8",
+		CreatedAt:            now,
+		ExpiresAt:            now.Add(time.Minute),
+		RefreshExpiresAt:     now.Add(time.Hour),
+		RotatedAt:            now.Add(time.Second),
+	}
+
+	// Redis returns every hash field as a string
+	data := make(map[string]string)
+	for k, v := range marshalSession(session) {
+		data[k] = fmt.Sprint(v)
+	}
+	got, err := (&SessionRedisRepo{}).unmarshalSession(session.ID, data)
+	require.NoError(t, err)
+	assert.Equal(t, session, got)
+}
@@ -21,8 +21,8 @@ func NewPgUserRepository(db *pgxpool.Pool) *UserPgeRepo {
 
 func (r *UserPgeRepo) CreateUser(ctx context.Context, user *models.User) error {
 	const op = "repository.UserPgeRepo.CreateUser"
-	query := `INSERT INTO users (id, email, password, created_at, is_active) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(ctx, query, user.ID, user.Email, user.Password, user.CreatedAt, user.IsActive)
+	query := `INSERT INTO users (id, email, password, created_at, is_active, email_verified_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.Exec(ctx, query, user.ID, user.Email, user.Password, user.CreatedAt, user.IsActive, user.EmailVerifiedAt)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -36,13 +36,16 @@ func (r *UserPgeRepo) CreateUser(ctx context.Context, user *models.User) error {
 func (r *UserPgeRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	const op = "repository.UserPgeRepo.GetUserByEmail"
 	var user models.User
-	query := `SELECT id, email, password, created_at, is_active FROM users WHERE email = $1`
+	query := `SELECT id, email, password, created_at, is_active, deactivated_at, deactivation_reason, email_verified_at FROM users WHERE email = $1`
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
 		&user.CreatedAt,
-		&user.IsActive)
+		&user.IsActive,
+		&user.DeactivatedAt,
+		&user.DeactivationReason,
+		&user.EmailVerifiedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrUserNotFound
@@ -55,12 +58,15 @@ func (r *UserPgeRepo) GetUserByEmail(ctx context.Context, email string) (*models
 func (r *UserPgeRepo) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	const op = "repository.UserPgeRepo.GetUserByID"
 	var user models.User
-	err := r.db.QueryRow(ctx, `SELECT id, email, password, created_at, is_active FROM users WHERE id = $1`, id).Scan(
+	err := r.db.QueryRow(ctx, `SELECT id, email, password, created_at, is_active, deactivated_at, deactivation_reason, email_verified_at FROM users WHERE id = $1`, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
 		&user.CreatedAt,
-		&user.IsActive)
+		&user.IsActive,
+		&user.DeactivatedAt,
+		&user.DeactivationReason,
+		&user.EmailVerifiedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrUserNotFound
@@ -82,3 +88,48 @@ func (r *UserPgeRepo) UpdateUserPassword(ctx context.Context, user *models.User)
 	}
 	return nil
 }
+
+// UpdateUserStatus persists the activation state of the user together with
+// the deactivation timestamp and reason.
+func (r *UserPgeRepo) UpdateUserStatus(ctx context.Context, user *models.User) error {
+	const op = "repository.UserPgeRepo.UpdateUserStatus"
+	query := `UPDATE users SET is_active = $1, deactivated_at = $2, deactivation_reason = $3 WHERE id = $4`
+	res, err := r.db.Exec(ctx, query, user.IsActive, user.DeactivatedAt, user.DeactivationReason, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UserPgeRepo) UpdateUserEmail(ctx context.Context, user *models.User) error {
+	const op = "repository.UserPgeRepo.UpdateUserEmail"
+	query := `UPDATE users SET email = $1, email_verified_at = $2 WHERE id = $3`
+	res, err := r.db.Exec(ctx, query, user.Email, user.EmailVerifiedAt, user.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return domain.ErrUserAlreadyExists
+		}
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UserPgeRepo) MarkEmailVerified(ctx context.Context, user *models.User) error {
+	const op = "repository.UserPgeRepo.MarkEmailVerified"
+	query := `UPDATE users SET email_verified_at = $1 WHERE id = $2 AND email = $3`
+	res, err := r.db.Exec(ctx, query, user.EmailVerifiedAt, user.ID, user.Email)
+	if err != nil {
+		return fmt.Errorf("%s, %w", op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
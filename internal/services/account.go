@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/jwt"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/mail"
+	"strings"
+	"time"
+)
+
+const (
+	purposeEmailChange       = "email_change"
+	purposeEmailVerification = "email_verification"
+)
+
+type AccountRepo interface {
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	UpdateUserEmail(ctx context.Context, user *models.User) error
+	MarkEmailVerified(ctx context.Context, user *models.User) error
+	UpdateUserPassword(ctx context.Context, user *models.User) error
+}
+
+// ActionTokenStore keeps track of the pending action token per user and
+// purpose, making action tokens single-use.
+type ActionTokenStore interface {
+	Save(ctx context.Context, purpose, userID, tokenID string, ttl time.Duration) error
+	Consume(ctx context.Context, purpose, userID, tokenID string) error
+}
+
+// PasswordResetStore keeps password reset tokens, by hash only.
+type PasswordResetStore interface {
+	Save(ctx context.Context, userID, tokenHash string, ttl time.Duration) error
+	Consume(ctx context.Context, tokenHash string) (string, error)
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg *mail.Message) error
+}
+
+// mailTimeout bounds the work sendInBackground does after the call has
+// been answered.
+const mailTimeout = 30 * time.Second
+
+// sendInBackground runs send once the caller has been answered, so flows
+// that must not reveal whether an address is registered take as long for
+// unknown addresses as for known ones. The context keeps the call's log
+// data but not its cancellation. Errors are only logged.
+func sendInBackground(ctx context.Context, log *logger.Logger, msg string, send func(ctx context.Context) error) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+		defer cancel()
+		if err := send(ctx); err != nil {
+			log.WarnContext(ctx, msg, log.String("error", err.Error()))
+		}
+	}()
+}
+
+// AccountService implements the account flows confirmed through a token
+// sent by mail.
+type AccountService struct {
+	users    AccountRepo
+	sessions SessionRevoker
+	tokens   *jwt.Manager
+	pending  ActionTokenStore
+	resets   PasswordResetStore
+	hasher   PasswordHasher
+	mailer   Mailer
+	security *config.SecurityConfig
+	mail     *config.MailConfig
+	logger   *logger.Logger
+}
+
+func NewAccountService(
+	users AccountRepo,
+	sessions SessionRevoker,
+	tokens *jwt.Manager,
+	pending ActionTokenStore,
+	resets PasswordResetStore,
+	hasher PasswordHasher,
+	mailer Mailer,
+	cfg *config.Config,
+	logger *logger.Logger,
+) *AccountService {
+	return &AccountService{
+		users:    users,
+		sessions: sessions,
+		tokens:   tokens,
+		pending:  pending,
+		resets:   resets,
+		hasher:   hasher,
+		mailer:   mailer,
+		security: cfg.Security,
+		mail:     cfg.Mail,
+		logger:   logger,
+	}
+}
+
+// RequestEmailChange sends a confirmation token to the new address. The
+// email is only changed once the token is confirmed, requesting another
+// change invalidates the previous token. It reports whether a change is
+// pending, which is not the case when the address is unchanged.
+func (s *AccountService) RequestEmailChange(ctx context.Context, uid, newEmail string) (*models.User, bool, error) {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid, "new_email": newEmail})
+	user, err := s.users.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, false, logger.WrapError(ctx, err)
+	}
+	if strings.EqualFold(user.Email, newEmail) {
+		return user, false, nil
+	}
+	if _, err = s.users.GetUserByEmail(ctx, newEmail); err == nil {
+		return nil, false, logger.WrapError(ctx, domain.ErrUserAlreadyExists)
+	} else if !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, false, logger.WrapError(ctx, err)
+	}
+
+	ttl := s.security.EmailChangeTokenTTL
+	token, claims, err := s.tokens.GenerateActionToken(purposeEmailChange, uid, newEmail, ttl)
+	if err != nil {
+		return nil, false, logger.WrapError(ctx, err)
+	}
+	if err = s.pending.Save(ctx, purposeEmailChange, uid, claims.ID, ttl); err != nil {
+		return nil, false, logger.WrapError(ctx, err)
+	}
+	if err = s.mailer.Send(ctx, mail.EmailChange(newEmail, s.mail.EmailChangeURL, token)); err != nil {
+		return nil, false, logger.WrapError(ctx, err)
+	}
+	return user, true, nil
+}
+
+// ConfirmEmailChange applies the change carried by the token and notifies
+// the previous address.
+func (s *AccountService) ConfirmEmailChange(ctx context.Context, token string) (*models.User, error) {
+	claims, err := s.tokens.ValidateActionToken(token, purposeEmailChange)
+	if err != nil {
+		return nil, logger.WrapError(ctx, ErrInvalidActionToken)
+	}
+	ctx = logger.WithData(ctx, map[string]any{"uid": claims.UserID, "new_email": claims.Email})
+
+	user, err := s.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	if !user.IsActive {
+		return nil, logger.WrapError(ctx, domain.ErrUserInactive)
+	}
+	if err = s.pending.Consume(ctx, purposeEmailChange, claims.UserID, claims.ID); err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+
+	oldEmail := user.Email
+	now := time.Now()
+	user.Email = claims.Email
+	// confirming the token proves ownership of the new address
+	user.EmailVerifiedAt = &now
+	if err = s.users.UpdateUserEmail(ctx, user); err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	if err = s.mailer.Send(ctx, mail.EmailChanged(oldEmail, user.Email)); err != nil {
+		// the change is done, a lost notification must not undo it
+		s.logger.WarnContext(ctx, "failed to notify previous email address", s.logger.String("error", err.Error()))
+	}
+	return user, nil
+}
+
+// SendEmailVerification mails a verification token to the user's address.
+// Requesting it again invalidates the previous token.
+func (s *AccountService) SendEmailVerification(ctx context.Context, user *models.User) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": user.ID.String(), "email": user.Email})
+	if user.EmailVerified() {
+		return nil
+	}
+	uid := user.ID.String()
+	ttl := s.security.EmailVerificationTTL
+	token, claims, err := s.tokens.GenerateActionToken(purposeEmailVerification, uid, user.Email, ttl)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if err = s.pending.Save(ctx, purposeEmailVerification, uid, claims.ID, ttl); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if err = s.mailer.Send(ctx, mail.EmailVerification(user.Email, s.mail.EmailVerificationURL, token)); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+// NotifyDuplicateRegistration tells the owner of the address that someone
+// tried to register it again, pointing to the password reset.
+func (s *AccountService) NotifyDuplicateRegistration(ctx context.Context, email string) error {
+	ctx = logger.WithData(ctx, map[string]any{"email": email})
+	if err := s.mailer.Send(ctx, mail.DuplicateRegistration(email, s.mail.PasswordResetURL)); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+func (s *AccountService) ResendEmailVerification(ctx context.Context, uid string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	user, err := s.users.GetUserByID(ctx, uid)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return s.SendEmailVerification(ctx, user)
+}
+
+// VerifyEmail confirms the address the token was sent to. A token issued for
+// an address the user has since changed is rejected.
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	claims, err := s.tokens.ValidateActionToken(token, purposeEmailVerification)
+	if err != nil {
+		return logger.WrapError(ctx, ErrInvalidActionToken)
+	}
+	ctx = logger.WithData(ctx, map[string]any{"uid": claims.UserID, "email": claims.Email})
+
+	user, err := s.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if !strings.EqualFold(user.Email, claims.Email) {
+		return logger.WrapError(ctx, ErrInvalidActionToken)
+	}
+	if err = s.pending.Consume(ctx, purposeEmailVerification, claims.UserID, claims.ID); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if user.EmailVerified() {
+		return nil
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err = s.users.MarkEmailVerified(ctx, user); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+// RequestPasswordReset mails a reset token to the account with this email.
+// It succeeds whether or not such an account exists, so it can't be used to
+// find out which addresses are registered: the token is saved and mailed
+// after the call returns.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx = logger.WithData(ctx, map[string]any{"email": email})
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	sendInBackground(ctx, s.logger, "failed to send password reset", func(ctx context.Context) error {
+		token, err := newOpaqueToken()
+		if err != nil {
+			return err
+		}
+		if err = s.resets.Save(ctx, user.ID.String(), hashToken(token), s.security.PasswordResetTokenTTL); err != nil {
+			return err
+		}
+		return s.mailer.Send(ctx, mail.PasswordReset(user.Email, s.mail.PasswordResetURL, token))
+	})
+	return nil
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset
+// and signs the user out of every session.
+func (s *AccountService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	uid, err := s.resets.Consume(ctx, hashToken(token))
+	if errors.Is(err, domain.ErrActionTokenUsed) {
+		return logger.WrapError(ctx, ErrInvalidActionToken)
+	}
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+
+	user, err := s.users.GetUserByID(ctx, uid)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if !user.IsActive {
+		return logger.WrapError(ctx, domain.ErrUserInactive)
+	}
+	user.Password, err = s.hasher.Hash(ctx, newPassword)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if err = s.users.UpdateUserPassword(ctx, user); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if _, err = s.sessions.RevokeAllByUser(ctx, uid, ""); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+// newOpaqueToken returns 256 random bits, URL safe encoded.
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken is what opaque tokens are stored as. The tokens are random, so
+// a plain SHA-256 is enough, there is nothing to brute-force.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
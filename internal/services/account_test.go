@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/mail"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+const knownEmail = "known@example.com"
+
+// userByEmail only knows the active user with knownEmail.
+func userByEmail(email string) (*models.User, error) {
+	if email != knownEmail {
+		return nil, domain.ErrUserNotFound
+	}
+	return &models.User{ID: uuid.New(), Email: email, IsActive: true}, nil
+}
+
+type accountUsers struct {
+	AccountRepo
+}
+
+func (accountUsers) GetUserByEmail(_ context.Context, email string) (*models.User, error) {
+	return userByEmail(email)
+}
+
+type resetStore struct {
+	PasswordResetStore
+	saved chan string
+}
+
+func (s resetStore) Save(_ context.Context, userID, _ string, _ time.Duration) error {
+	s.saved <- userID
+	return nil
+}
+
+// blockingMailer holds every message until release is closed.
+type blockingMailer struct {
+	release chan struct{}
+	sent    chan *mail.Message
+}
+
+func newBlockingMailer() *blockingMailer {
+	return &blockingMailer{release: make(chan struct{}), sent: make(chan *mail.Message, 1)}
+}
+
+func (m *blockingMailer) Send(ctx context.Context, msg *mail.Message) error {
+	select {
+	case <-m.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	m.sent <- msg
+	return nil
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Security: &config.SecurityConfig{
+			PasswordResetTokenTTL: time.Hour,
+			PasswordlessCodeTTL:   time.Minute,
+			PasswordlessLinkTTL:   time.Minute,
+		},
+		Mail: &config.MailConfig{},
+	}
+}
+
+func testLogger() *logger.Logger {
+	return &logger.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+// returnsWithin fails the test if call hasn't returned after d.
+func returnsWithin(t *testing.T, d time.Duration, call func() error) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- call() }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(d):
+		t.Fatal("call waited for the mail to be sent")
+	}
+}
+
+func TestAccount_RequestPasswordResetDoesNotWaitForMail(t *testing.T) {
+	mailer := newBlockingMailer()
+	resets := resetStore{saved: make(chan string, 1)}
+	s := NewAccountService(accountUsers{}, nil, nil, nil, resets, nil, mailer, testConfig(), testLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// the known address returns while its mail is still blocked, so it
+	// can't return any later than the unknown one
+	returnsWithin(t, time.Second, func() error { return s.RequestPasswordReset(ctx, knownEmail) })
+	returnsWithin(t, time.Second, func() error { return s.RequestPasswordReset(ctx, "unknown@example.com") })
+
+	// the mail still goes out once the call's context is gone
+	cancel()
+	close(mailer.release)
+	select {
+	case msg := <-mailer.sent:
+		assert.Equal(t, knownEmail, msg.To)
+	case <-time.After(time.Second):
+		t.Fatal("password reset was not sent")
+	}
+	assert.Len(t, resets.saved, 1)
+	assert.Len(t, mailer.sent, 0)
+}
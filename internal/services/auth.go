@@ -2,13 +2,17 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"fmt"
 	"github.com/Roflan4eg/auth-serivce/config"
 	"github.com/Roflan4eg/auth-serivce/internal/domain"
 	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/jwt"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/tracing"
 	"github.com/google/uuid"
+	"strconv"
 	"time"
 )
 
@@ -18,7 +22,10 @@ type SessionRepo interface {
 	Update(ctx context.Context, session *models.Session) error
 	//Delete(ctx context.Context, sessionID string) error
 	Revoke(ctx context.Context, sessionID string) error
+	Rotate(ctx context.Context, session *models.Session, expectedRefreshToken string) error
 	Exists(ctx context.Context, sessionID string) (bool, error)
+	ListByUser(ctx context.Context, userID string, offset, limit int) ([]*models.Session, int, error)
+	RevokeAllByUser(ctx context.Context, userID, exceptSessionID string) (int, error)
 	//UpdateSessionActivity(ctx context.Context, sessionID string) error
 }
 
@@ -26,29 +33,138 @@ type UserClient interface {
 	CreateUser(ctx context.Context, email, password string) (*models.User, error)
 	GetUserByID(ctx context.Context, id string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	RehashPassword(ctx context.Context, user *models.User, password string) error
+}
+
+const (
+	defaultSessionPageSize = 20
+	maxSessionPageSize     = 100
+)
+
+type AccessProvider interface {
+	GetUserAccess(ctx context.Context, userID string) (*models.UserAccess, error)
+}
+
+// AccountMailer sends the mails following a registration.
+type AccountMailer interface {
+	SendEmailVerification(ctx context.Context, user *models.User) error
+	NotifyDuplicateRegistration(ctx context.Context, email string) error
+}
+
+// MFAProvider completes logins of users with two-factor authentication.
+type MFAProvider interface {
+	Enabled(ctx context.Context, userID string) (bool, error)
+	Challenge(ctx context.Context, userID string) (*models.MFAChallenge, error)
+	Redeem(ctx context.Context, challenge, code string) (string, error)
+}
+
+// PasskeyVerifier resolves a finished passkey login into the user ID.
+type PasskeyVerifier interface {
+	FinishLogin(ctx context.Context, ceremonyID string, response []byte) (string, error)
+}
+
+// PasswordlessRedeemer resolves a passwordless login secret into the user.
+type PasswordlessRedeemer interface {
+	Redeem(ctx context.Context, token, email, code string) (*models.User, error)
+}
+
+// LoginThrottle blocks password logins after repeated failures.
+type LoginThrottle interface {
+	Check(ctx context.Context, email, ip string) error
+	Failed(ctx context.Context, email, uid string, client models.ClientInfo) error
+	Succeeded(ctx context.Context, email string) error
+}
+
+type SecurityEventSink interface {
+	Emit(ctx context.Context, event *models.SecurityEvent)
 }
 
 type AuthService struct {
-	repo       SessionRepo
-	userClient UserClient
-	jwtManager *jwt.Manager
+	repo                 SessionRepo
+	userClient           UserClient
+	access               AccessProvider
+	mailer               AccountMailer
+	mfa                  MFAProvider
+	passkeys             PasskeyVerifier
+	passwordless         PasswordlessRedeemer
+	throttle             LoginThrottle
+	hasher               PasswordHasher
+	dummyHash            []byte
+	jwtManager           *jwt.Manager
+	events               SecurityEventSink
+	reuseGrace           time.Duration
+	requireVerifiedEmail bool
+	silentDuplicates     bool
+	logger               *logger.Logger
 }
 
-func NewAuthService(repo SessionRepo, userClient UserClient, conf *config.JWTConfig) *AuthService {
-	jwtManager, err := jwt.NewManager(conf)
+func NewAuthService(
+	repo SessionRepo,
+	userClient UserClient,
+	access AccessProvider,
+	mailer AccountMailer,
+	mfa MFAProvider,
+	passkeys PasskeyVerifier,
+	passwordless PasswordlessRedeemer,
+	throttle LoginThrottle,
+	hasher PasswordHasher,
+	events SecurityEventSink,
+	jwtManager *jwt.Manager,
+	cfg *config.Config,
+	logger *logger.Logger,
+) *AuthService {
+	// checked when there is no hash to check, so a login takes as long
+	// whether or not the account, or its password, exists
+	dummyHash, err := hasher.Hash(context.Background(), rand.Text())
 	if err != nil {
 		panic(err)
 	}
-	return &AuthService{repo: repo, userClient: userClient, jwtManager: jwtManager}
+	return &AuthService{
+		repo:                 repo,
+		userClient:           userClient,
+		access:               access,
+		mailer:               mailer,
+		mfa:                  mfa,
+		passkeys:             passkeys,
+		passwordless:         passwordless,
+		throttle:             throttle,
+		hasher:               hasher,
+		dummyHash:            dummyHash,
+		jwtManager:           jwtManager,
+		events:               events,
+		reuseGrace:           cfg.JWTConfig.RefreshReuseGrace,
+		requireVerifiedEmail: cfg.Security.RequireVerifiedEmail,
+		silentDuplicates:     cfg.Security.SilentDuplicateRegistration,
+		logger:               logger,
+	}
 }
 
-func (s *AuthService) Register(ctx context.Context, email, password string) (*models.Session, error) {
+// Register creates the account and opens a session. With silent duplicate
+// registration no session is opened, the owner of the address gets a mail
+// instead: the verification mail for a new account, a notice for an
+// existing one. The caller can't tell which addresses are registered.
+func (s *AuthService) Register(ctx context.Context, email, password string, client models.ClientInfo) (*models.Session, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Register")
+	defer span.End()
 	ctx = logger.WithData(ctx, map[string]any{"email": email, "password": password})
 	newUser, err := s.userClient.CreateUser(ctx, email, password)
+	if errors.Is(err, domain.ErrUserAlreadyExists) && s.silentDuplicates {
+		if err = s.mailer.NotifyDuplicateRegistration(ctx, email); err != nil {
+			s.logger.WarnContext(ctx, "failed to send duplicate registration notice", s.logger.String("error", err.Error()))
+		}
+		return nil, nil
+	}
 	if err != nil {
 		return nil, logger.WrapError(ctx, err) //!!!
 	}
-	ses, err := s.createSession(ctx, newUser)
+	if err = s.mailer.SendEmailVerification(ctx, newUser); err != nil {
+		// the account exists, the user can ask for the mail again
+		s.logger.WarnContext(ctx, "failed to send email verification", s.logger.String("error", err.Error()))
+	}
+	if s.silentDuplicates {
+		return nil, nil
+	}
+	ses, err := s.createSession(ctx, newUser, client)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
@@ -56,27 +172,170 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*mo
 	return ses, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*models.Session, error) {
-	ctx = logger.WithData(ctx, map[string]any{"email": email, "password": password})
+// Login checks the credentials and opens a session. For users with
+// two-factor authentication no session is opened yet, a challenge to be
+// completed with VerifyMFA is returned instead. Failed logins are throttled
+// per account and IP, blocked attempts fail with a *RetryAfterError before
+// the password is checked. Unknown addresses, accounts without a password
+// and wrong passwords all fail alike with domain.ErrInvalidCredentials,
+// after a password hash has been checked. The hash checked for them uses the
+// current parameters, so accounts still on a legacy hash (t=1, p=4) answer
+// at a different cost until their next login rehashes them: that gap is
+// accepted rather than keeping a dummy hash per parameter set.
+func (s *AuthService) Login(ctx context.Context, email, password string, client models.ClientInfo) (ses *models.Session, challenge *models.MFAChallenge, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Login")
+	defer span.End()
+	defer func() { recordLogin(loginMethodPassword, challenge, err) }()
+	ctx = logger.WithData(ctx, map[string]any{"email": email, "password": password, "ip": client.IpAddress})
+	if err = s.throttle.Check(ctx, email, client.IpAddress); err != nil {
+		return nil, nil, logger.WrapError(ctx, err)
+	}
 	user, err := s.userClient.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil, logger.WrapError(ctx, err)
+	}
+	hash, uid := s.dummyHash, ""
+	if user != nil {
+		uid = user.ID.String()
+		if len(user.Password) > 0 {
+			hash = user.Password
+		}
+	}
+	isValidPass, rehash, err := s.hasher.Verify(ctx, password, hash)
+	if err != nil {
+		return nil, nil, logger.WrapError(ctx, err)
+	}
+	if !isValidPass || user == nil || len(user.Password) == 0 {
+		if err = s.throttle.Failed(ctx, email, uid, client); err != nil {
+			return nil, nil, logger.WrapError(ctx, err)
+		}
+		return nil, nil, logger.WrapError(ctx, domain.ErrInvalidCredentials)
+	}
+	if err = s.throttle.Succeeded(ctx, email); err != nil {
+		return nil, nil, logger.WrapError(ctx, err)
+	}
+	if rehash {
+		// the login succeeded regardless, the next one retries the upgrade
+		if err = s.userClient.RehashPassword(ctx, user, password); err != nil {
+			s.logger.WarnContext(ctx, "failed to rehash password", s.logger.String("error", err.Error()))
+		}
+	}
+	ses, challenge, err = s.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, nil, logger.WrapError(ctx, err)
+	}
+	return ses, challenge, nil
+}
+
+// LoginPasswordless signs in with a link token, or an email and code, from
+// StartPasswordlessLogin. Like Login it returns an MFA challenge instead of
+// a session for users with two-factor authentication.
+func (s *AuthService) LoginPasswordless(ctx context.Context, token, email, code string, client models.ClientInfo) (ses *models.Session, challenge *models.MFAChallenge, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.LoginPasswordless")
+	defer span.End()
+	defer func() { recordLogin(loginMethodPasswordless, challenge, err) }()
+	ctx = logger.WithData(ctx, map[string]any{"email": email})
+	user, err := s.passwordless.Redeem(ctx, token, email, code)
+	if err != nil {
+		return nil, nil, logger.WrapError(ctx, err)
+	}
+	ctx = logger.WithData(ctx, map[string]any{"uid": user.ID.String()})
+	ses, challenge, err = s.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, nil, logger.WrapError(ctx, err)
+	}
+	return ses, challenge, nil
+}
+
+// completeLogin opens a session for a user whose first factor was checked,
+// or returns the challenge for the second one.
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User, client models.ClientInfo) (*models.Session, *models.MFAChallenge, error) {
+	if err := s.checkLoginAllowed(user); err != nil {
+		return nil, nil, err
+	}
+	mfaEnabled, err := s.mfa.Enabled(ctx, user.ID.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	if mfaEnabled {
+		challenge, err := s.mfa.Challenge(ctx, user.ID.String())
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, challenge, nil
+	}
+	ses, err := s.createSession(ctx, user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ses, nil, nil
+}
+
+// VerifyMFA completes a login started by Login with a TOTP or recovery code.
+func (s *AuthService) VerifyMFA(ctx context.Context, challenge, code string, client models.ClientInfo) (ses *models.Session, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.VerifyMFA")
+	defer span.End()
+	defer func() { recordLogin(loginMethodMFA, nil, err) }()
+	uid, err := s.mfa.Redeem(ctx, challenge, code)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	user, err := s.userClient.GetUserByID(ctx, uid)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
-	isValidPass, err := VerifyPassword(password, user.Password)
+	if err = s.checkLoginAllowed(user); err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	ses, err = s.createSession(ctx, user, client)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	return ses, nil
+}
+
+// LoginWithPasskey opens a session for the owner of the passkey that signed
+// the challenge of the ceremony. A passkey already proves possession and,
+// with user verification, knowledge or biometrics, so no TOTP challenge
+// follows.
+func (s *AuthService) LoginWithPasskey(ctx context.Context, ceremonyID string, response []byte, client models.ClientInfo) (ses *models.Session, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.LoginWithPasskey")
+	defer span.End()
+	defer func() { recordLogin(loginMethodPasskey, nil, err) }()
+	ctx = logger.WithData(ctx, map[string]any{"ceremony_id": ceremonyID})
+	uid, err := s.passkeys.FinishLogin(ctx, ceremonyID, response)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	user, err := s.userClient.GetUserByID(ctx, uid)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
-	if !isValidPass {
-		return nil, logger.WrapError(ctx, domain.ErrInvalidPassword)
+	if err = s.checkLoginAllowed(user); err != nil {
+		return nil, logger.WrapError(ctx, err)
 	}
-	ses, err := s.createSession(ctx, user)
+	ses, err = s.createSession(ctx, user, client)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
 	return ses, nil
 }
 
+func (s *AuthService) checkLoginAllowed(user *models.User) error {
+	if !user.IsActive {
+		return domain.ErrUserInactive
+	}
+	if s.requireVerifiedEmail && !user.EmailVerified() {
+		return domain.ErrEmailNotVerified
+	}
+	return nil
+}
+
 func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Logout")
+	defer span.End()
 	ctx = logger.WithData(ctx, map[string]any{"session_id": sessionID})
 	if err := s.repo.Revoke(ctx, sessionID); err != nil {
 		return logger.WrapError(ctx, err)
@@ -84,26 +343,35 @@ func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
 	return nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*models.Session, error) {
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (ses *models.Session, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.RefreshToken")
+	defer span.End()
+	defer func() { recordRefresh(err) }()
 	ctx = logger.WithData(ctx, map[string]any{"refresh_token": refreshToken})
-	token, err := s.jwtManager.ValidateToken(refreshToken)
+	token, err := s.jwtManager.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		switch {
-		case errors.Is(err, jwt.ErrInvalidTokenFormat):
-			err = ErrInvalidRefreshToken
 		case errors.Is(err, jwt.ErrTokenMalformed):
 			err = ErrTokenMalformed
+		case errors.Is(err, jwt.ErrTokenExpired):
+			err = ErrTokenExpired
+		default:
+			// e.g. an access token: the jwt error is kept for the metrics
+			err = fmt.Errorf("%w: %w", ErrInvalidRefreshToken, err)
 		}
 		return nil, logger.WrapError(ctx, err)
 	}
-	ses, err := s.repo.GetById(ctx, token.SessionID)
+	ses, err = s.repo.GetById(ctx, token.SessionID)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
 	if ses.RefreshToken != refreshToken {
-		return nil, logger.WrapError(ctx, ErrInvalidRefreshToken)
+		return s.handleRotatedRefreshToken(ctx, ses, refreshToken)
 	}
-	newAccessToken, err := s.jwtManager.GenerateAccessToken(ses.UserID, ses.ID)
+	if err = s.ensureActive(ctx, ses.UserID); err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	newAccessToken, err := s.generateAccessToken(ctx, ses.UserID, ses.ID)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
@@ -112,46 +380,214 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, logger.WrapError(ctx, err)
 	}
 	ses.AccessToken = newAccessToken
+	ses.PreviousRefreshToken = refreshToken
 	ses.RefreshToken = newRefreshToken
+	ses.RotatedAt = time.Now()
 	ses.ExpiresAt = time.Now().Add(s.jwtManager.GetAccessTokenTTL())
 
-	err = s.repo.Update(ctx, ses)
+	err = s.repo.Rotate(ctx, ses, refreshToken)
+	if errors.Is(err, domain.ErrSessionRotated) {
+		// a concurrent refresh with the same token won the race
+		current, err := s.repo.GetById(ctx, ses.ID)
+		if err != nil {
+			return nil, logger.WrapError(ctx, err)
+		}
+		return s.handleRotatedRefreshToken(ctx, current, refreshToken)
+	}
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
 	return ses, nil
 }
 
-func (s *AuthService) ValidateToken(ctx context.Context, accessToken string) *models.ValidateTokenResponse {
+// handleRotatedRefreshToken deals with a correctly signed refresh token that
+// is no longer the current one of its session. The token that was replaced
+// last is tolerated for reuseGrace, so that concurrent refreshes from the same
+// client get the already rotated session. Anything else means a rotated-out
+// token is being replayed: the whole token family is revoked.
+func (s *AuthService) handleRotatedRefreshToken(ctx context.Context, ses *models.Session, refreshToken string) (*models.Session, error) {
+	if ses.PreviousRefreshToken == refreshToken && time.Since(ses.RotatedAt) <= s.reuseGrace {
+		return ses, nil
+	}
+
+	if err := s.repo.Revoke(ctx, ses.ID); err != nil && !errors.Is(err, domain.ErrSessionExpired) {
+		return nil, logger.WrapError(ctx, err)
+	}
+	s.events.Emit(ctx, &models.SecurityEvent{
+		Type:      models.EventRefreshTokenReuse,
+		UserID:    ses.UserID,
+		SessionID: ses.ID,
+		IpAddress: ses.IpAddress,
+		UserAgent: ses.UserAgent,
+		Details:   map[string]any{"rotated_at": ses.RotatedAt},
+	})
+	return nil, logger.WrapError(ctx, ErrRefreshTokenReused)
+}
+
+func (s *AuthService) ValidateToken(ctx context.Context, accessToken, audience string) *models.ValidateTokenResponse {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.ValidateToken")
+	defer span.End()
 
 	resp := &models.ValidateTokenResponse{Valid: false, Error: ""}
 
-	token, err := s.jwtManager.ValidateToken(accessToken)
-	if err != nil {
-		resp.Error = err.Error()
-		return resp
+	var audiences []string
+	if audience != "" {
+		audiences = append(audiences, audience)
 	}
-	ses, err := s.repo.GetById(ctx, token.SessionID)
+	_, ses, err := s.verifyAccessToken(ctx, accessToken, audiences)
 	if err != nil {
 		resp.Error = err.Error()
 		return resp
 	}
-	if ses.AccessToken != accessToken {
-		resp.Error = ErrInvalidAccessToken.Error()
-		return resp
-	}
 	resp.UserId = ses.UserID
 	resp.SessionId = ses.ID
 	resp.Valid = true
 	return resp
 }
 
-func (s *AuthService) createSession(ctx context.Context, user *models.User) (*models.Session, error) {
+// Authenticate resolves an access token into the principal it was issued
+// for. The token must be correctly signed and still be the current access
+// token of a live session.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*models.Principal, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.Authenticate")
+	defer span.End()
+	claims, ses, err := s.verifyAccessToken(ctx, accessToken, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Principal{
+		UserID:    ses.UserID,
+		SessionID: ses.ID,
+		Roles:     claims.Roles,
+		Scopes:    claims.Scopes,
+	}, nil
+}
+
+func (s *AuthService) verifyAccessToken(ctx context.Context, accessToken string, audiences []string) (claims *jwt.Claims, ses *models.Session, err error) {
+	defer func() {
+		if err != nil {
+			recordTokenRejected(err)
+		}
+	}()
+	claims, err = s.jwtManager.ValidateToken(accessToken, audiences...)
+	if err != nil {
+		return nil, nil, err
+	}
+	ses, err = s.repo.GetById(ctx, claims.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ses.AccessToken != accessToken {
+		return nil, nil, ErrInvalidAccessToken
+	}
+	if err = s.ensureActive(ctx, ses.UserID); err != nil {
+		return nil, nil, err
+	}
+	return claims, ses, nil
+}
+
+// ensureActive rejects users deactivated after their session was created.
+// Deactivation revokes sessions too, this closes the window in between.
+func (s *AuthService) ensureActive(ctx context.Context, userID string) error {
+	user, err := s.userClient.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return domain.ErrUserInactive
+	}
+	return nil
+}
+
+func (s *AuthService) GetSession(ctx context.Context, sessionID string) (*models.Session, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.GetSession")
+	defer span.End()
+	ctx = logger.WithData(ctx, map[string]any{"session_id": sessionID})
+	ses, err := s.repo.GetById(ctx, sessionID)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	return ses, nil
+}
+
+// ListSessions returns a page of the user's live sessions. The page token is
+// opaque to callers, an empty next token means there are no more pages.
+func (s *AuthService) ListSessions(ctx context.Context, userID string, pageSize int, pageToken string) (*models.SessionPage, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.ListSessions")
+	defer span.End()
+	ctx = logger.WithData(ctx, map[string]any{"user_id": userID, "page_size": pageSize, "page_token": pageToken})
+	if pageSize <= 0 {
+		pageSize = defaultSessionPageSize
+	}
+	if pageSize > maxSessionPageSize {
+		pageSize = maxSessionPageSize
+	}
+	offset := 0
+	if pageToken != "" {
+		var err error
+		offset, err = strconv.Atoi(pageToken)
+		if err != nil || offset < 0 {
+			return nil, logger.WrapError(ctx, ErrInvalidPageToken)
+		}
+	}
+
+	sessions, total, err := s.repo.ListByUser(ctx, userID, offset, pageSize)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	page := &models.SessionPage{Sessions: sessions, Total: total}
+	if offset+pageSize < total {
+		page.NextPageToken = strconv.Itoa(offset + pageSize)
+	}
+	return page, nil
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, sessionID string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.RevokeSession")
+	defer span.End()
+	ctx = logger.WithData(ctx, map[string]any{"session_id": sessionID})
+	if err := s.repo.Revoke(ctx, sessionID); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+// RevokeAllSessions logs the user out everywhere, except exceptSessionID when
+// it is set.
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID, exceptSessionID string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "AuthService.RevokeAllSessions")
+	defer span.End()
+	ctx = logger.WithData(ctx, map[string]any{"user_id": userID, "except_session_id": exceptSessionID})
+	if _, err := s.repo.RevokeAllByUser(ctx, userID, exceptSessionID); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+// JWKS returns the public signing keys for /.well-known/jwks.json.
+func (s *AuthService) JWKS() *jwt.JWKS {
+	return s.jwtManager.JWKS()
+}
+
+// generateAccessToken issues an access token carrying the user's current
+// roles and permissions.
+func (s *AuthService) generateAccessToken(ctx context.Context, userID, sessionID string) (string, error) {
+	access, err := s.access.GetUserAccess(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return s.jwtManager.GenerateAccessToken(userID, sessionID,
+		jwt.WithRoles(access.Roles...),
+		jwt.WithScopes(access.Permissions...),
+	)
+}
+
+func (s *AuthService) createSession(ctx context.Context, user *models.User, client models.ClientInfo) (*models.Session, error) {
 	sessUuid, err := uuid.NewV7()
 	if err != nil {
 		return nil, err
 	}
-	accessToken, err := s.jwtManager.GenerateAccessToken(user.ID.String(), sessUuid.String())
+	accessToken, err := s.generateAccessToken(ctx, user.ID.String(), sessUuid.String())
 	if err != nil {
 		return nil, err
 	}
@@ -168,8 +604,10 @@ func (s *AuthService) createSession(ctx context.Context, user *models.User) (*mo
 		CreatedAt:        time.Now(),
 		AccessToken:      accessToken,
 		RefreshToken:     refreshToken,
-		UserAgent:        "",
-		IpAddress:        "",
+		UserAgent:        client.UserAgent,
+		IpAddress:        client.IpAddress,
+		DeviceID:         client.DeviceID,
+		DeviceName:       client.DeviceName,
 		//IsRevoked:    false,
 	}
 
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+)
+
+// adminRole is seeded with every permission by the roles migration.
+const adminRole = "admin"
+
+// BootstrapAdmins grants the admin role to the accounts listed in
+// conf.BootstrapAdmins. Accounts that are not registered yet, or whose
+// address is unverified while verified addresses are required, are skipped
+// until the next start. Granting a role twice is a no-op, so it runs on
+// every start.
+func BootstrapAdmins(ctx context.Context, users *UserService, roles *RoleService, conf *config.SecurityConfig, log *logger.Logger) error {
+	for _, email := range conf.BootstrapAdmins {
+		user, err := users.GetUserByEmail(ctx, email)
+		if errors.Is(err, domain.ErrUserNotFound) {
+			log.WarnContext(ctx, "bootstrap admin is not registered yet", log.String("email", email))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if conf.RequireVerifiedEmail && !user.EmailVerified() {
+			log.WarnContext(ctx, "bootstrap admin has not verified the email address", log.String("email", email))
+			continue
+		}
+		if err = roles.AssignRole(ctx, user.ID.String(), adminRole); err != nil {
+			return err
+		}
+		log.InfoContext(ctx, "admin role granted", log.String("email", email))
+	}
+	return nil
+}
@@ -2,13 +2,22 @@ package services
 
 import (
 	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/audit"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/jwt"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/mail"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/password"
 	"github.com/Roflan4eg/auth-serivce/internal/repository"
 )
 
 type Container struct {
-	UserService *UserService
-	AuthService *AuthService
+	UserService         *UserService
+	AuthService         *AuthService
+	RoleService         *RoleService
+	AccountService      *AccountService
+	MFAService          *MFAService
+	PasskeyService      *PasskeyService
+	PasswordlessService *PasswordlessService
 }
 
 func NewContainer(
@@ -16,8 +25,91 @@ func NewContainer(
 	cfg *config.Config,
 	logger *logger.Logger,
 ) *Container {
-	userService := NewUserService(repository.UserRepo, logger)
-	authService := NewAuthService(repository.SessionRepo, userService, cfg.JWTConfig)
+	events := audit.NewLogSink(logger)
+	hasher := password.NewHasher(cfg.Password)
+	loginGuard := NewLoginGuard(
+		repository.LoginBlockRepo,
+		repository.AttemptRepo,
+		events,
+		cfg.Security,
+	)
+	userService := NewUserService(
+		repository.UserRepo,
+		hasher,
+		repository.SessionRepo,
+		repository.AttemptRepo,
+		loginGuard,
+		events,
+		cfg.Security,
+		logger,
+	)
+	roleService := NewRoleService(repository.RoleRepo)
+	jwtManager, err := jwt.NewManager(cfg.JWTConfig)
+	if err != nil {
+		panic(err)
+	}
+	mailer, err := mail.New(cfg.Mail, logger)
+	if err != nil {
+		panic(err)
+	}
+	accountService := NewAccountService(
+		repository.UserRepo,
+		repository.SessionRepo,
+		jwtManager,
+		repository.TokenRepo,
+		repository.ResetRepo,
+		hasher,
+		mailer,
+		cfg,
+		logger,
+	)
+	mfaService := NewMFAService(
+		repository.MFARepo,
+		userService,
+		jwtManager,
+		repository.TokenRepo,
+		repository.AttemptRepo,
+		events,
+		cfg.MFA,
+	)
+	passkeyService := NewPasskeyService(
+		repository.PasskeyRepo,
+		repository.CeremonyRepo,
+		userService,
+		events,
+		cfg.WebAuthn,
+	)
+	passwordlessService := NewPasswordlessService(
+		repository.UserRepo,
+		repository.PasswordlessRepo,
+		repository.AttemptRepo,
+		mailer,
+		cfg,
+		logger,
+	)
+	authService := NewAuthService(
+		repository.SessionRepo,
+		userService,
+		roleService,
+		accountService,
+		mfaService,
+		passkeyService,
+		passwordlessService,
+		loginGuard,
+		hasher,
+		events,
+		jwtManager,
+		cfg,
+		logger,
+	)
 
-	return &Container{UserService: userService, AuthService: authService}
+	return &Container{
+		UserService:         userService,
+		AuthService:         authService,
+		RoleService:         roleService,
+		AccountService:      accountService,
+		MFAService:          mfaService,
+		PasskeyService:      passkeyService,
+		PasswordlessService: passwordlessService,
+	}
 }
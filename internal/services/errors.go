@@ -1,10 +1,37 @@
 package services
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrInvalidAccessToken  = errors.New("invalid access token")
 	ErrInvalidRefreshToken = errors.New("invalid refresh token")
 	ErrTokenMalformed      = errors.New("token malformed")
 	ErrTokenExpired        = errors.New("token expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrInvalidPageToken    = errors.New("invalid page token")
+	ErrTooManyAttempts     = errors.New("too many attempts, try again later")
+	ErrInvalidActionToken  = errors.New("invalid or expired token")
+	ErrMFANotConfigured    = errors.New("two-factor authentication is not configured")
+	ErrInvalidPasskey      = errors.New("passkey verification failed")
+	ErrInvalidLoginCode    = errors.New("invalid or expired code")
+	ErrLoginDelayed        = errors.New("too many failed logins, try again later")
+	ErrAccountLocked       = errors.New("account temporarily locked after too many failed logins")
 )
+
+// RetryAfterError refuses a call that may be retried once RetryAfter has
+// passed.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
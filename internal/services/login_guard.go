@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"time"
+)
+
+const (
+	loginBlockDelay   = "delay"
+	loginBlockLockout = "lockout"
+)
+
+// LoginBlockStore keeps the blocks put on logins, Blocked returns an empty
+// reason for keys that are not blocked.
+type LoginBlockStore interface {
+	Block(ctx context.Context, key, reason string, d time.Duration) error
+	Blocked(ctx context.Context, key string) (string, time.Duration, error)
+	Unblock(ctx context.Context, key string) error
+}
+
+// LoginGuard slows down password guessing. Failed logins are counted per
+// account and per IP: after a few failures every further one blocks logins
+// for an exponentially growing delay, and an account that keeps failing is
+// locked for a while. Accounts are keyed by the email address, so unknown
+// addresses are throttled like registered ones.
+type LoginGuard struct {
+	blocks   LoginBlockStore
+	attempts AttemptCounter
+	events   SecurityEventSink
+	conf     *config.SecurityConfig
+}
+
+func NewLoginGuard(
+	blocks LoginBlockStore,
+	attempts AttemptCounter,
+	events SecurityEventSink,
+	conf *config.SecurityConfig,
+) *LoginGuard {
+	return &LoginGuard{
+		blocks:   blocks,
+		attempts: attempts,
+		events:   events,
+		conf:     conf,
+	}
+}
+
+// Check refuses the login while the account or the IP is blocked, with a
+// *RetryAfterError wrapping ErrAccountLocked or ErrLoginDelayed.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) error {
+	keys := []string{accountLoginKey(email)}
+	if ip != "" {
+		keys = append(keys, ipLoginKey(ip))
+	}
+	for _, key := range keys {
+		reason, retryAfter, err := g.blocks.Blocked(ctx, key)
+		if err != nil {
+			return err
+		}
+		switch reason {
+		case loginBlockLockout:
+			return &RetryAfterError{Err: ErrAccountLocked, RetryAfter: retryAfter}
+		case loginBlockDelay:
+			return &RetryAfterError{Err: ErrLoginDelayed, RetryAfter: retryAfter}
+		}
+	}
+	return nil
+}
+
+// Failed records a failed login and blocks further ones when a threshold is
+// reached. uid is empty when no account has the address.
+func (g *LoginGuard) Failed(ctx context.Context, email, uid string, client models.ClientInfo) error {
+	key := accountLoginKey(email)
+	failed, err := g.attempts.Hit(ctx, key, g.conf.LoginFailureWindow)
+	if err != nil {
+		return err
+	}
+	switch {
+	case failed >= g.conf.LoginLockoutAfter:
+		if err = g.blocks.Block(ctx, key, loginBlockLockout, g.conf.LoginLockoutDuration); err != nil {
+			return err
+		}
+		g.events.Emit(ctx, &models.SecurityEvent{
+			Type:      models.EventAccountLocked,
+			UserID:    uid,
+			IpAddress: client.IpAddress,
+			UserAgent: client.UserAgent,
+			Details: map[string]any{
+				"failed_attempts": failed,
+				"retry_after":     g.conf.LoginLockoutDuration.String(),
+			},
+		})
+	case failed >= g.conf.LoginDelayAfter:
+		if err = g.blocks.Block(ctx, key, loginBlockDelay, g.delay(failed-g.conf.LoginDelayAfter)); err != nil {
+			return err
+		}
+	}
+
+	if client.IpAddress == "" {
+		return nil
+	}
+	key = ipLoginKey(client.IpAddress)
+	failed, err = g.attempts.Hit(ctx, key, g.conf.LoginFailureWindow)
+	if err != nil {
+		return err
+	}
+	if failed >= g.conf.LoginIPDelayAfter {
+		return g.blocks.Block(ctx, key, loginBlockDelay, g.delay(failed-g.conf.LoginIPDelayAfter))
+	}
+	return nil
+}
+
+// Succeeded forgets the failures of the account. Failures of the IP are
+// kept, a successful login from an IP says nothing about its other ones.
+func (g *LoginGuard) Succeeded(ctx context.Context, email string) error {
+	return g.attempts.Reset(ctx, accountLoginKey(email))
+}
+
+// Unlock lifts the block on the account and forgets its failures.
+func (g *LoginGuard) Unlock(ctx context.Context, email string) error {
+	key := accountLoginKey(email)
+	if err := g.blocks.Unblock(ctx, key); err != nil {
+		return err
+	}
+	return g.attempts.Reset(ctx, key)
+}
+
+// delay returns LoginDelayBase doubled n times, at most LoginDelayMax.
+func (g *LoginGuard) delay(n int) time.Duration {
+	d := g.conf.LoginDelayBase
+	for i := 0; i < n && d < g.conf.LoginDelayMax; i++ {
+		d *= 2
+	}
+	return min(d, g.conf.LoginDelayMax)
+}
+
+func accountLoginKey(email string) string {
+	return "login:account:" + hashEmail(email)
+}
+
+func ipLoginKey(ip string) string {
+	return "login:ip:" + ip
+}
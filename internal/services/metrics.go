@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/jwt"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/metrics"
+)
+
+const (
+	loginMethodPassword     = "password"
+	loginMethodPasswordless = "passwordless"
+	loginMethodPasskey      = "passkey"
+	loginMethodMFA          = "mfa"
+)
+
+// recordLogin counts a finished login. Logins answered with an MFA
+// challenge count as mfa_required, the second step as method mfa.
+func recordLogin(method string, challenge *models.MFAChallenge, err error) {
+	outcome := "success"
+	switch {
+	case err == nil && challenge != nil:
+		outcome = "mfa_required"
+	case err == nil:
+	case errors.Is(err, domain.ErrInvalidCredentials),
+		errors.Is(err, domain.ErrInvalidMFACode),
+		errors.Is(err, domain.ErrCeremonyNotFound),
+		errors.Is(err, ErrInvalidPasskey),
+		errors.Is(err, ErrInvalidLoginCode),
+		errors.Is(err, ErrInvalidActionToken):
+		outcome = "invalid_credentials"
+	case errors.Is(err, ErrAccountLocked):
+		outcome = "locked"
+	case errors.Is(err, ErrLoginDelayed), errors.Is(err, ErrTooManyAttempts):
+		outcome = "throttled"
+	case errors.Is(err, domain.ErrUserInactive):
+		outcome = "inactive"
+	case errors.Is(err, domain.ErrEmailNotVerified):
+		outcome = "unverified"
+	default:
+		outcome = "error"
+	}
+	metrics.Logins.WithLabelValues(method, outcome).Inc()
+}
+
+func recordRefresh(err error) {
+	outcome := "success"
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrRefreshTokenReused):
+		outcome = "reused"
+	default:
+		outcome = tokenFailureReason(err)
+	}
+	metrics.TokenRefreshes.WithLabelValues(outcome).Inc()
+}
+
+func recordTokenRejected(err error) {
+	metrics.TokenValidationFailures.WithLabelValues(tokenFailureReason(err)).Inc()
+}
+
+func tokenFailureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired), errors.Is(err, ErrTokenExpired):
+		return "expired"
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return "not_yet_valid"
+	case errors.Is(err, jwt.ErrInvalidIssuer):
+		return "invalid_issuer"
+	case errors.Is(err, jwt.ErrInvalidAudience):
+		return "invalid_audience"
+	case errors.Is(err, jwt.ErrTokenMalformed), errors.Is(err, ErrTokenMalformed):
+		return "malformed"
+	case errors.Is(err, jwt.ErrInvalidTokenFormat), errors.Is(err, ErrInvalidRefreshToken):
+		return "invalid"
+	case errors.Is(err, domain.ErrSessionNotFound), errors.Is(err, domain.ErrSessionExpired):
+		return "revoked"
+	case errors.Is(err, ErrInvalidAccessToken):
+		// correctly signed, but replaced by a refresh
+		return "superseded"
+	case errors.Is(err, domain.ErrUserInactive):
+		return "inactive"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/jwt"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/secretbox"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/totp"
+	"strings"
+	"time"
+)
+
+const (
+	purposeMFA       = "mfa"
+	recoveryCodeSize = 10
+)
+
+type MFARepo interface {
+	GetTOTP(ctx context.Context, userID string) (*models.TOTP, error)
+	SaveTOTP(ctx context.Context, t *models.TOTP) error
+	EnableTOTP(ctx context.Context, userID string, enabledAt time.Time, codeHashes []string) error
+	DeleteTOTP(ctx context.Context, userID string) error
+	UseStep(ctx context.Context, userID string, step int64) error
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) error
+}
+
+// MFAService implements TOTP two-factor authentication and the challenge
+// that completes a login once the password was checked.
+type MFAService struct {
+	repo     MFARepo
+	users    UserClient
+	box      *secretbox.Box
+	tokens   *jwt.Manager
+	pending  ActionTokenStore
+	attempts AttemptCounter
+	events   SecurityEventSink
+	conf     *config.MFAConfig
+}
+
+func NewMFAService(
+	repo MFARepo,
+	users UserClient,
+	tokens *jwt.Manager,
+	pending ActionTokenStore,
+	attempts AttemptCounter,
+	events SecurityEventSink,
+	conf *config.MFAConfig,
+) *MFAService {
+	var box *secretbox.Box
+	if conf.EncryptionKey != "" {
+		var err error
+		box, err = secretbox.New(conf.EncryptionKey)
+		if err != nil {
+			panic(err)
+		}
+	}
+	return &MFAService{
+		repo:     repo,
+		users:    users,
+		box:      box,
+		tokens:   tokens,
+		pending:  pending,
+		attempts: attempts,
+		events:   events,
+		conf:     conf,
+	}
+}
+
+// EnrollTOTP generates a new secret for the user. It has no effect on
+// logins until a code is confirmed with ConfirmTOTP.
+func (s *MFAService) EnrollTOTP(ctx context.Context, uid string) (*models.TOTPEnrollment, error) {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	if s.box == nil {
+		return nil, logger.WrapError(ctx, ErrMFANotConfigured)
+	}
+	user, err := s.users.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	sealed, err := s.box.Seal([]byte(secret))
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	err = s.repo.SaveTOTP(ctx, &models.TOTP{UserID: uid, Secret: sealed, CreatedAt: time.Now()})
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URL:    totp.URL(s.conf.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proved their
+// authenticator works, and returns the recovery codes. They are only stored
+// hashed, so this is the only time they can be shown.
+func (s *MFAService) ConfirmTOTP(ctx context.Context, uid, code string) ([]string, error) {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	t, err := s.repo.GetTOTP(ctx, uid)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	if t.Enabled() {
+		return nil, logger.WrapError(ctx, domain.ErrMFAAlreadyEnabled)
+	}
+	if err = s.verifyTOTP(ctx, t, code); err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+
+	codes := make([]string, s.conf.RecoveryCodes)
+	hashes := make([]string, len(codes))
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, logger.WrapError(ctx, err)
+		}
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	if err = s.repo.EnableTOTP(ctx, uid, time.Now(), hashes); err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	s.events.Emit(ctx, &models.SecurityEvent{Type: models.EventMFAEnabled, UserID: uid})
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off. It takes a current code
+// or a recovery code, so a stolen session alone can't remove the protection.
+// Codes are counted together with the ones of Redeem, so the session can't
+// be used to guess them either.
+func (s *MFAService) DisableTOTP(ctx context.Context, uid, code string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	t, err := s.repo.GetTOTP(ctx, uid)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if t.Enabled() {
+		if err = s.countAttempt(ctx, uid); err != nil {
+			return logger.WrapError(ctx, err)
+		}
+		if err = s.verifyCode(ctx, t, code); err != nil {
+			return logger.WrapError(ctx, err)
+		}
+	}
+	if err = s.repo.DeleteTOTP(ctx, uid); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if err = s.attempts.Reset(ctx, attemptsKeyMFA(uid)); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	s.events.Emit(ctx, &models.SecurityEvent{Type: models.EventMFADisabled, UserID: uid})
+	return nil
+}
+
+func (s *MFAService) Enabled(ctx context.Context, uid string) (bool, error) {
+	t, err := s.repo.GetTOTP(ctx, uid)
+	if errors.Is(err, domain.ErrMFANotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return t.Enabled(), nil
+}
+
+// Challenge issues the single-use token Login returns in place of a session.
+func (s *MFAService) Challenge(ctx context.Context, uid string) (*models.MFAChallenge, error) {
+	token, claims, err := s.tokens.GenerateActionToken(purposeMFA, uid, "", s.conf.ChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.pending.Save(ctx, purposeMFA, uid, claims.ID, s.conf.ChallengeTTL); err != nil {
+		return nil, err
+	}
+	return &models.MFAChallenge{Token: token, ExpiresAt: claims.ExpiresAt.Time}, nil
+}
+
+// Redeem checks the code for a challenge and returns the user it was issued
+// to. Wrong codes are counted per user, after MaxAttempts the user has to
+// wait for the window to expire.
+func (s *MFAService) Redeem(ctx context.Context, challenge, code string) (string, error) {
+	claims, err := s.tokens.ValidateActionToken(challenge, purposeMFA)
+	if err != nil {
+		return "", ErrInvalidActionToken
+	}
+	uid := claims.UserID
+	if err = s.countAttempt(ctx, uid); err != nil {
+		return "", err
+	}
+	t, err := s.repo.GetTOTP(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	if err = s.verifyCode(ctx, t, code); err != nil {
+		return "", err
+	}
+	if err = s.pending.Consume(ctx, purposeMFA, uid, claims.ID); err != nil {
+		return "", err
+	}
+	if err = s.attempts.Reset(ctx, attemptsKeyMFA(uid)); err != nil {
+		return "", err
+	}
+	return uid, nil
+}
+
+// countAttempt counts a code of the user before it is checked, so
+// concurrent guesses can't all pass MaxAttempts. The count is reset once a
+// code is accepted.
+func (s *MFAService) countAttempt(ctx context.Context, uid string) error {
+	attempts, err := s.attempts.Hit(ctx, attemptsKeyMFA(uid), s.conf.ChallengeTTL)
+	if err != nil {
+		return err
+	}
+	if attempts > s.conf.MaxAttempts {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+func attemptsKeyMFA(uid string) string {
+	return "mfa:" + uid
+}
+
+// verifyCode accepts either a TOTP code or an unused recovery code.
+func (s *MFAService) verifyCode(ctx context.Context, t *models.TOTP, code string) error {
+	if len(code) == totp.Digits {
+		return s.verifyTOTP(ctx, t, code)
+	}
+	if err := s.repo.UseRecoveryCode(ctx, t.UserID, hashToken(normalizeRecoveryCode(code))); err != nil {
+		return err
+	}
+	s.events.Emit(ctx, &models.SecurityEvent{Type: models.EventMFARecoveryCodeUsed, UserID: t.UserID})
+	return nil
+}
+
+// verifyTOTP checks the code and records its time step, so a code can't be
+// used twice, even within its validity window.
+func (s *MFAService) verifyTOTP(ctx context.Context, t *models.TOTP, code string) error {
+	if s.box == nil {
+		return ErrMFANotConfigured
+	}
+	secret, err := s.box.Open(t.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(string(secret), code, time.Now(), s.conf.Skew)
+	if !ok {
+		return domain.ErrInvalidMFACode
+	}
+	return s.repo.UseStep(ctx, t.UserID, step)
+}
+
+// newRecoveryCode returns 80 random bits formatted as XXXX-XXXX-XXXX-XXXX.
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := base32.StdEncoding.EncodeToString(buf)
+	return raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12] + "-" + raw[12:16], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
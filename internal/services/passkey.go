@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"time"
+)
+
+type PasskeyRepo interface {
+	Create(ctx context.Context, p *models.Passkey) error
+	GetByID(ctx context.Context, id []byte) (*models.Passkey, error)
+	ListByUser(ctx context.Context, userID string) ([]*models.Passkey, error)
+	UpdateUsage(ctx context.Context, id []byte, signCount uint32, backupState bool, usedAt time.Time) error
+}
+
+// CeremonyStore keeps the state of begun passkey ceremonies, Take returns
+// it only once.
+type CeremonyStore interface {
+	Save(ctx context.Context, ceremonyID string, state []byte, ttl time.Duration) error
+	Take(ctx context.Context, ceremonyID string) ([]byte, error)
+}
+
+// PasskeyService runs the WebAuthn registration and login ceremonies. Both
+// are split in a begin call, returning the options for the authenticator,
+// and a finish call verifying its response against the stored challenge.
+type PasskeyService struct {
+	repo             PasskeyRepo
+	ceremonies       CeremonyStore
+	users            UserClient
+	webauthn         *webauthn.WebAuthn
+	events           SecurityEventSink
+	ttl              time.Duration
+	userVerification protocol.UserVerificationRequirement
+}
+
+func NewPasskeyService(
+	repo PasskeyRepo,
+	ceremonies CeremonyStore,
+	users UserClient,
+	events SecurityEventSink,
+	conf *config.WebAuthnConfig,
+) *PasskeyService {
+	userVerification := protocol.UserVerificationRequirement(conf.UserVerification)
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          conf.RPID,
+		RPDisplayName: conf.RPDisplayName,
+		RPOrigins:     conf.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   userVerification,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: conf.ChallengeTTL, TimeoutUVD: conf.ChallengeTTL},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: conf.ChallengeTTL, TimeoutUVD: conf.ChallengeTTL},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &PasskeyService{
+		repo:             repo,
+		ceremonies:       ceremonies,
+		users:            users,
+		webauthn:         wa,
+		events:           events,
+		ttl:              conf.ChallengeTTL,
+		userVerification: userVerification,
+	}
+}
+
+// BeginRegistration starts adding a passkey to the user's account. Passkeys
+// the user already has are excluded, so an authenticator is not registered
+// twice.
+func (s *PasskeyService) BeginRegistration(ctx context.Context, uid string) (*models.PasskeyCeremony, error) {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	owner, err := s.loadOwner(ctx, uid)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	creation, state, err := s.webauthn.BeginRegistration(owner,
+		webauthn.WithExclusions(webauthn.Credentials(owner.WebAuthnCredentials()).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	ceremony, err := s.begin(ctx, creation, state)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	return ceremony, nil
+}
+
+// FinishRegistration verifies the authenticator response and stores the
+// new passkey. The ceremony must have been begun by the same user.
+func (s *PasskeyService) FinishRegistration(ctx context.Context, uid, ceremonyID string, response []byte) (*models.Passkey, error) {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid, "ceremony_id": ceremonyID})
+	state, err := s.take(ctx, ceremonyID)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	owner, err := s.loadOwner(ctx, uid)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, logger.WrapError(ctx, invalidPasskey(err))
+	}
+	credential, err := s.webauthn.CreateCredential(owner, *state, parsed)
+	if err != nil {
+		return nil, logger.WrapError(ctx, invalidPasskey(err))
+	}
+
+	passkey := &models.Passkey{
+		ID:              credential.ID,
+		UserID:          uid,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      make([]string, 0, len(credential.Transport)),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+	for _, transport := range credential.Transport {
+		passkey.Transports = append(passkey.Transports, string(transport))
+	}
+	if err = s.repo.Create(ctx, passkey); err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	s.events.Emit(ctx, &models.SecurityEvent{
+		Type:    models.EventPasskeyRegistered,
+		UserID:  uid,
+		Details: map[string]any{"credential_id": base64.RawURLEncoding.EncodeToString(passkey.ID)},
+	})
+	return passkey, nil
+}
+
+// BeginLogin starts a login with a discoverable credential: the user is not
+// known yet, the authenticator lets them pick one of their passkeys.
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*models.PasskeyCeremony, error) {
+	assertion, state, err := s.webauthn.BeginDiscoverableLogin(webauthn.WithUserVerification(s.userVerification))
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	ceremony, err := s.begin(ctx, assertion, state)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	return ceremony, nil
+}
+
+// FinishLogin verifies the assertion and returns the user who owns the
+// passkey. A signature counter that did not increase means the key was
+// cloned, such logins are refused.
+func (s *PasskeyService) FinishLogin(ctx context.Context, ceremonyID string, response []byte) (string, error) {
+	state, err := s.take(ctx, ceremonyID)
+	if err != nil {
+		return "", err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return "", invalidPasskey(err)
+	}
+
+	var (
+		passkey   *models.Passkey
+		lookupErr error
+	)
+	findOwner := func(rawID, userHandle []byte) (webauthn.User, error) {
+		p, err := s.repo.GetByID(ctx, rawID)
+		if err != nil {
+			lookupErr = err
+			return nil, err
+		}
+		owner, err := s.loadOwner(ctx, p.UserID)
+		if err != nil {
+			lookupErr = err
+			return nil, err
+		}
+		if !bytes.Equal(owner.WebAuthnID(), userHandle) {
+			return nil, domain.ErrPasskeyNotFound
+		}
+		passkey = p
+		return owner, nil
+	}
+	_, credential, err := s.webauthn.ValidatePasskeyLogin(findOwner, *state, parsed)
+	if err != nil {
+		// storage failures are ours, everything else is a bad assertion
+		if lookupErr != nil && !errors.Is(lookupErr, domain.ErrPasskeyNotFound) {
+			return "", lookupErr
+		}
+		return "", invalidPasskey(err)
+	}
+
+	if credential.Authenticator.CloneWarning {
+		s.events.Emit(ctx, &models.SecurityEvent{
+			Type:   models.EventPasskeyCloneDetected,
+			UserID: passkey.UserID,
+			Details: map[string]any{
+				"credential_id":  base64.RawURLEncoding.EncodeToString(passkey.ID),
+				"stored_count":   passkey.SignCount,
+				"asserted_count": parsed.Response.AuthenticatorData.Counter,
+			},
+		})
+		return "", invalidPasskey(errors.New("signature counter did not increase"))
+	}
+	err = s.repo.UpdateUsage(ctx, credential.ID, credential.Authenticator.SignCount, credential.Flags.BackupState, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return passkey.UserID, nil
+}
+
+func (s *PasskeyService) begin(ctx context.Context, options any, state *webauthn.SessionData) (*models.PasskeyCeremony, error) {
+	rawOptions, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+	rawState, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	ceremonyID := uuid.NewString()
+	if err = s.ceremonies.Save(ctx, ceremonyID, rawState, s.ttl); err != nil {
+		return nil, err
+	}
+	return &models.PasskeyCeremony{
+		ID:        ceremonyID,
+		Options:   rawOptions,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}, nil
+}
+
+func (s *PasskeyService) take(ctx context.Context, ceremonyID string) (*webauthn.SessionData, error) {
+	rawState, err := s.ceremonies.Take(ctx, ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+	var state webauthn.SessionData
+	if err = json.Unmarshal(rawState, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *PasskeyService) loadOwner(ctx context.Context, uid string) (*passkeyOwner, error) {
+	user, err := s.users.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	passkeys, err := s.repo.ListByUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return &passkeyOwner{user: user, passkeys: passkeys}, nil
+}
+
+func invalidPasskey(err error) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPasskey, err.Error())
+}
+
+// passkeyOwner adapts a user and their passkeys to webauthn.User. The user
+// handle is the raw user ID, so no personal data is stored on authenticators
+// beyond the email shown while picking a passkey.
+type passkeyOwner struct {
+	user     *models.User
+	passkeys []*models.Passkey
+}
+
+func (o *passkeyOwner) WebAuthnID() []byte {
+	return o.user.ID[:]
+}
+
+func (o *passkeyOwner) WebAuthnName() string {
+	return o.user.Email
+}
+
+func (o *passkeyOwner) WebAuthnDisplayName() string {
+	return o.user.Email
+}
+
+func (o *passkeyOwner) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(o.passkeys))
+	for _, p := range o.passkeys {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(p.Transports))
+		for _, transport := range p.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+		credentials = append(credentials, webauthn.Credential{
+			ID:              p.ID,
+			PublicKey:       p.PublicKey,
+			AttestationType: p.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: p.BackupEligible,
+				BackupState:    p.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    p.AAGUID,
+				SignCount: p.SignCount,
+			},
+		})
+	}
+	return credentials
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/domain"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/mail"
+	"math/big"
+	"time"
+)
+
+type PasswordlessMode int
+
+const (
+	PasswordlessLink PasswordlessMode = iota
+	PasswordlessCode
+)
+
+const loginCodeDigits = 6
+
+type PasswordlessUserRepo interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	MarkEmailVerified(ctx context.Context, user *models.User) error
+}
+
+// PasswordlessStore keeps the pending link or code of every address, by
+// hash only.
+type PasswordlessStore interface {
+	SaveLink(ctx context.Context, emailHash, email, tokenHash string, ttl time.Duration) error
+	SaveCode(ctx context.Context, emailHash, codeHash string, ttl time.Duration) error
+	TakeLink(ctx context.Context, tokenHash string) (string, error)
+	ConsumePending(ctx context.Context, emailHash, secretHash string) error
+}
+
+// PasswordlessService signs users in with a link or a code sent to their
+// address instead of a password.
+type PasswordlessService struct {
+	users    PasswordlessUserRepo
+	store    PasswordlessStore
+	attempts AttemptCounter
+	mailer   Mailer
+	security *config.SecurityConfig
+	mail     *config.MailConfig
+	logger   *logger.Logger
+}
+
+func NewPasswordlessService(
+	users PasswordlessUserRepo,
+	store PasswordlessStore,
+	attempts AttemptCounter,
+	mailer Mailer,
+	cfg *config.Config,
+	logger *logger.Logger,
+) *PasswordlessService {
+	return &PasswordlessService{
+		users:    users,
+		store:    store,
+		attempts: attempts,
+		mailer:   mailer,
+		security: cfg.Security,
+		mail:     cfg.Mail,
+		logger:   logger,
+	}
+}
+
+// Start mails a sign-in link or code to the address. Like
+// RequestPasswordReset it succeeds for every address and saves and mails
+// the secret after the call returns, so it doesn't reveal which are
+// registered. Unknown addresses only get mail when sign-up is enabled.
+func (s *PasswordlessService) Start(ctx context.Context, email string, mode PasswordlessMode) error {
+	ctx = logger.WithData(ctx, map[string]any{"email": email, "mode": mode})
+	user, err := s.users.GetUserByEmail(ctx, email)
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		if !s.security.PasswordlessSignUp {
+			return nil
+		}
+	case err != nil:
+		return logger.WrapError(ctx, err)
+	case !user.IsActive:
+		return nil
+	}
+
+	sendInBackground(ctx, s.logger, "failed to send passwordless login", func(ctx context.Context) error {
+		return s.send(ctx, email, mode)
+	})
+	return nil
+}
+
+// send saves a new link or code for the address and mails it.
+func (s *PasswordlessService) send(ctx context.Context, email string, mode PasswordlessMode) error {
+	var msg *mail.Message
+	emailHash := hashEmail(email)
+	switch mode {
+	case PasswordlessCode:
+		code, err := newLoginCode()
+		if err != nil {
+			return err
+		}
+		ttl := s.security.PasswordlessCodeTTL
+		if err = s.store.SaveCode(ctx, emailHash, hashLoginCode(email, code), ttl); err != nil {
+			return err
+		}
+		msg = mail.PasswordlessCode(email, code, ttl)
+	default:
+		token, err := newOpaqueToken()
+		if err != nil {
+			return err
+		}
+		if err = s.store.SaveLink(ctx, emailHash, email, hashToken(token), s.security.PasswordlessLinkTTL); err != nil {
+			return err
+		}
+		msg = mail.PasswordlessLink(email, s.mail.PasswordlessLoginURL, token)
+	}
+	return s.mailer.Send(ctx, msg)
+}
+
+// Redeem checks a link token, or an email and code, and returns the user
+// signing in. Users are created on first sign-in, receiving the secret
+// proves the address, so it is marked verified. Wrong codes are counted per
+// address, after PasswordlessMaxAttempts the address has to wait for the
+// code to expire.
+func (s *PasswordlessService) Redeem(ctx context.Context, token, email, code string) (*models.User, error) {
+	var err error
+	if token != "" {
+		email, err = s.redeemLink(ctx, token)
+	} else {
+		err = s.redeemCode(ctx, email, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) && s.security.PasswordlessSignUp {
+		return s.signUp(ctx, email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.EmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err = s.users.MarkEmailVerified(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+func (s *PasswordlessService) redeemLink(ctx context.Context, token string) (string, error) {
+	tokenHash := hashToken(token)
+	email, err := s.store.TakeLink(ctx, tokenHash)
+	if err == nil {
+		err = s.store.ConsumePending(ctx, hashEmail(email), tokenHash)
+	}
+	if errors.Is(err, domain.ErrActionTokenUsed) {
+		return "", ErrInvalidActionToken
+	}
+	return email, err
+}
+
+func (s *PasswordlessService) redeemCode(ctx context.Context, email, code string) error {
+	emailHash := hashEmail(email)
+	key := "passwordless:" + emailHash
+	// counted before the check, so concurrent guesses can't all pass the
+	// limit, and reset once one succeeds
+	attempts, err := s.attempts.Hit(ctx, key, s.security.PasswordlessCodeTTL)
+	if err != nil {
+		return err
+	}
+	if attempts > s.security.PasswordlessMaxAttempts {
+		return ErrTooManyAttempts
+	}
+	err = s.store.ConsumePending(ctx, emailHash, hashLoginCode(email, code))
+	if errors.Is(err, domain.ErrActionTokenUsed) {
+		return ErrInvalidLoginCode
+	}
+	if err != nil {
+		return err
+	}
+	return s.attempts.Reset(ctx, key)
+}
+
+func (s *PasswordlessService) signUp(ctx context.Context, email string) (*models.User, error) {
+	user, err := newUser(email, nil)
+	if err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = &user.CreatedAt
+	if err = s.users.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// newLoginCode returns a random numeric code of loginCodeDigits digits.
+func newLoginCode() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(loginCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", loginCodeDigits, n), nil
+}
+
+// hashLoginCode binds the code to the address. Codes are short, this keeps
+// a leaked hash from being matched against every pending code at once.
+func hashLoginCode(email, code string) string {
+	return hashToken(hashEmail(email) + ":" + code)
+}
+
+// hashEmail keeps addresses out of cache keys.
+func hashEmail(email string) string {
+	return hashToken(email)
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type passwordlessUsers struct {
+	PasswordlessUserRepo
+}
+
+func (passwordlessUsers) GetUserByEmail(_ context.Context, email string) (*models.User, error) {
+	return userByEmail(email)
+}
+
+type codeStore struct {
+	PasswordlessStore
+	saved chan string
+}
+
+func (s codeStore) SaveCode(_ context.Context, emailHash, _ string, _ time.Duration) error {
+	s.saved <- emailHash
+	return nil
+}
+
+func TestPasswordless_StartDoesNotWaitForMail(t *testing.T) {
+	mailer := newBlockingMailer()
+	store := codeStore{saved: make(chan string, 1)}
+	s := NewPasswordlessService(passwordlessUsers{}, store, nil, mailer, testConfig(), testLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	returnsWithin(t, time.Second, func() error { return s.Start(ctx, knownEmail, PasswordlessCode) })
+	returnsWithin(t, time.Second, func() error { return s.Start(ctx, "unknown@example.com", PasswordlessCode) })
+
+	cancel()
+	close(mailer.release)
+	select {
+	case msg := <-mailer.sent:
+		assert.Equal(t, knownEmail, msg.To)
+	case <-time.After(time.Second):
+		t.Fatal("login code was not sent")
+	}
+	assert.Equal(t, hashEmail(knownEmail), <-store.saved)
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+)
+
+type RoleRepo interface {
+	GetUserAccess(ctx context.Context, userID string) (*models.UserAccess, error)
+	AssignRole(ctx context.Context, userID, role string) error
+	RevokeRole(ctx context.Context, userID, role string) error
+}
+
+// RoleService manages user roles. Roles and the permissions they grant are
+// embedded into access tokens, so changes take effect with the next token
+// refresh.
+type RoleService struct {
+	repo RoleRepo
+}
+
+func NewRoleService(repo RoleRepo) *RoleService {
+	return &RoleService{repo: repo}
+}
+
+func (s *RoleService) GetUserAccess(ctx context.Context, userID string) (*models.UserAccess, error) {
+	ctx = logger.WithData(ctx, map[string]any{"uid": userID})
+	access, err := s.repo.GetUserAccess(ctx, userID)
+	if err != nil {
+		return nil, logger.WrapError(ctx, err)
+	}
+	return access, nil
+}
+
+func (s *RoleService) AssignRole(ctx context.Context, userID, role string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": userID, "role": role})
+	if err := s.repo.AssignRole(ctx, userID, role); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+func (s *RoleService) RevokeRole(ctx context.Context, userID, role string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": userID, "role": role})
+	if err := s.repo.RevokeRole(ctx, userID, role); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
@@ -2,16 +2,24 @@ package services
 
 import (
 	"context"
+	"github.com/Roflan4eg/auth-serivce/config"
 	"github.com/Roflan4eg/auth-serivce/internal/domain"
 	"github.com/Roflan4eg/auth-serivce/internal/domain/models"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/authctx"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
 	"github.com/google/uuid"
 	"time"
 )
 
 type UserService struct {
-	storage UserRepo
-	logger  *logger.Logger
+	storage  UserRepo
+	hasher   PasswordHasher
+	sessions SessionRevoker
+	attempts AttemptCounter
+	logins   LoginUnlocker
+	events   SecurityEventSink
+	conf     *config.SecurityConfig
+	logger   *logger.Logger
 }
 
 type UserRepo interface {
@@ -19,10 +27,45 @@ type UserRepo interface {
 	GetUserByID(ctx context.Context, id string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	UpdateUserPassword(ctx context.Context, user *models.User) error
+	UpdateUserStatus(ctx context.Context, user *models.User) error
 }
 
-func NewUserService(storage UserRepo, logger *logger.Logger) *UserService {
-	return &UserService{storage: storage, logger: logger}
+type SessionRevoker interface {
+	RevokeAllByUser(ctx context.Context, userID, exceptSessionID string) (int, error)
+}
+
+// AttemptCounter counts attempts per key within a time window.
+type AttemptCounter interface {
+	Hit(ctx context.Context, key string, window time.Duration) (int, error)
+	Count(ctx context.Context, key string) (int, time.Duration, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginUnlocker lifts the lockout put on an account after failed logins.
+type LoginUnlocker interface {
+	Unlock(ctx context.Context, email string) error
+}
+
+func NewUserService(
+	storage UserRepo,
+	hasher PasswordHasher,
+	sessions SessionRevoker,
+	attempts AttemptCounter,
+	logins LoginUnlocker,
+	events SecurityEventSink,
+	conf *config.SecurityConfig,
+	logger *logger.Logger,
+) *UserService {
+	return &UserService{
+		storage:  storage,
+		hasher:   hasher,
+		sessions: sessions,
+		attempts: attempts,
+		logins:   logins,
+		events:   events,
+		conf:     conf,
+		logger:   logger,
+	}
 }
 
 func (s *UserService) CreateUser(ctx context.Context, email, password string) (*models.User, error) {
@@ -30,21 +73,14 @@ func (s *UserService) CreateUser(ctx context.Context, email, password string) (*
 		"email":    email,
 		"password": password,
 	})
-	pass, err := HashPass(password)
+	pass, err := s.hasher.Hash(ctx, password)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
-	id, err := uuid.NewV7()
+	user, err := newUser(email, pass)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
 	}
-	user := &models.User{
-		ID:        id,
-		Email:     email,
-		CreatedAt: time.Now(),
-		IsActive:  true,
-		Password:  pass,
-	}
 	err = s.storage.CreateUser(ctx, user)
 	if err != nil {
 		return nil, logger.WrapError(ctx, err)
@@ -52,6 +88,20 @@ func (s *UserService) CreateUser(ctx context.Context, email, password string) (*
 	return user, nil
 }
 
+func newUser(email string, password []byte) (*models.User, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	return &models.User{
+		ID:        id,
+		Email:     email,
+		CreatedAt: time.Now(),
+		IsActive:  true,
+		Password:  password,
+	}, nil
+}
+
 func (s *UserService) GetUserByID(ctx context.Context, uid string) (*models.User, error) {
 	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
 	user, err := s.storage.GetUserByID(ctx, uid)
@@ -70,6 +120,31 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models
 	return user, nil
 }
 
+// RehashPassword replaces the stored hash of the user's password, which the
+// caller just verified, by one made with the current parameters.
+func (s *UserService) RehashPassword(ctx context.Context, user *models.User, password string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": user.ID.String()})
+	hash, err := s.hasher.Hash(ctx, password)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	updated := *user
+	updated.Password = hash
+	if err = s.storage.UpdateUserPassword(ctx, &updated); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	user.Password = hash
+	return nil
+}
+
+// upgradePasswordHash rehashes a verified password. The check succeeded
+// regardless, a failure only postpones the upgrade to the next one.
+func (s *UserService) upgradePasswordHash(ctx context.Context, user *models.User, password string) {
+	if err := s.RehashPassword(ctx, user, password); err != nil {
+		s.logger.WarnContext(ctx, "failed to rehash password", s.logger.String("error", err.Error()))
+	}
+}
+
 func (s *UserService) UpdateUserPassword(ctx context.Context, uid, oldPassword, newPassword string) error {
 	ctx = logger.WithData(ctx, map[string]any{"uid": uid, "oldPassword": oldPassword, "newPassword": newPassword})
 	user, err := s.storage.GetUserByID(ctx, uid)
@@ -77,14 +152,14 @@ func (s *UserService) UpdateUserPassword(ctx context.Context, uid, oldPassword,
 		return logger.WrapError(ctx, err)
 	}
 
-	isValidPass, err := VerifyPassword(oldPassword, user.Password)
+	isValidPass, _, err := s.hasher.Verify(ctx, oldPassword, user.Password)
 	if err != nil {
 		return logger.WrapError(ctx, err)
 	}
 	if !isValidPass {
 		return logger.WrapError(ctx, domain.ErrInvalidPassword)
 	}
-	newPass, err := HashPass(newPassword)
+	newPass, err := s.hasher.Hash(ctx, newPassword)
 	if err != nil {
 		return err
 	}
@@ -95,3 +170,125 @@ func (s *UserService) UpdateUserPassword(ctx context.Context, uid, oldPassword,
 	}
 	return nil
 }
+
+// DeactivateUser disables the account and signs it out of every session.
+// Deactivating an already inactive user only updates the reason.
+func (s *UserService) DeactivateUser(ctx context.Context, uid, reason string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid, "reason": reason})
+	user, err := s.storage.GetUserByID(ctx, uid)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if user.IsActive {
+		now := time.Now()
+		user.DeactivatedAt = &now
+	}
+	user.IsActive = false
+	user.DeactivationReason = reason
+	if err = s.storage.UpdateUserStatus(ctx, user); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if _, err = s.sessions.RevokeAllByUser(ctx, uid, ""); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+func (s *UserService) ReactivateUser(ctx context.Context, uid string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	user, err := s.storage.GetUserByID(ctx, uid)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	user.IsActive = true
+	user.DeactivatedAt = nil
+	user.DeactivationReason = ""
+	if err = s.storage.UpdateUserStatus(ctx, user); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	return nil
+}
+
+// UnlockUser lifts the lockout and the login delays put on the account
+// after failed logins.
+func (s *UserService) UnlockUser(ctx context.Context, uid string) error {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid})
+	user, err := s.storage.GetUserByID(ctx, uid)
+	if err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	if err = s.logins.Unlock(ctx, user.Email); err != nil {
+		return logger.WrapError(ctx, err)
+	}
+	event := &models.SecurityEvent{Type: models.EventAccountUnlocked, UserID: uid}
+	if p, ok := authctx.PrincipalFromContext(ctx); ok {
+		event.SessionID = p.SessionID
+		event.Details = map[string]any{"caller_id": p.UserID}
+	}
+	s.events.Emit(ctx, event)
+	return nil
+}
+
+// ValidatePassword re-confirms the user's password, e.g. before a sensitive
+// operation in another service. Failed checks are counted per user and once
+// PasswordCheckMaxAttempts is reached further checks are refused until the
+// window expires, so the call can't be used to brute-force a password. Every
+// check is recorded as a security event.
+func (s *UserService) ValidatePassword(ctx context.Context, uid, password string, client models.ClientInfo) (bool, error) {
+	ctx = logger.WithData(ctx, map[string]any{"uid": uid, "password": password})
+	key := "password_check:" + uid
+
+	event := &models.SecurityEvent{
+		UserID:    uid,
+		IpAddress: client.IpAddress,
+		UserAgent: client.UserAgent,
+		Details:   map[string]any{},
+	}
+	if p, ok := authctx.PrincipalFromContext(ctx); ok {
+		event.SessionID = p.SessionID
+		event.Details["caller_id"] = p.UserID
+	}
+
+	// counted before the check, so concurrent checks can't all pass the
+	// limit, and reset once one succeeds
+	attempts, err := s.attempts.Hit(ctx, key, s.conf.PasswordCheckWindow)
+	if err != nil {
+		return false, logger.WrapError(ctx, err)
+	}
+	if attempts > s.conf.PasswordCheckMaxAttempts {
+		event.Type = models.EventPasswordCheckThrottled
+		if _, retryAfter, err := s.attempts.Count(ctx, key); err == nil {
+			event.Details["retry_after"] = retryAfter.String()
+		}
+		s.events.Emit(ctx, event)
+		return false, logger.WrapError(ctx, ErrTooManyAttempts)
+	}
+
+	user, err := s.storage.GetUserByID(ctx, uid)
+	if err != nil {
+		return false, logger.WrapError(ctx, err)
+	}
+	if !user.IsActive {
+		return false, logger.WrapError(ctx, domain.ErrUserInactive)
+	}
+	valid, rehash, err := s.hasher.Verify(ctx, password, user.Password)
+	if err != nil {
+		return false, logger.WrapError(ctx, err)
+	}
+
+	if valid {
+		event.Type = models.EventPasswordCheckSucceeded
+		err = s.attempts.Reset(ctx, key)
+		if rehash {
+			s.upgradePasswordHash(ctx, user, password)
+		}
+	} else {
+		event.Type = models.EventPasswordCheckFailed
+		event.Details["failed_attempts"] = attempts
+	}
+	s.events.Emit(ctx, event)
+	if err != nil {
+		return false, logger.WrapError(ctx, err)
+	}
+	return valid, nil
+}
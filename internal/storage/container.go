@@ -8,16 +8,21 @@ import (
 
 type SQLStorage interface {
 	DB() any
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
 	Close() error
 }
 type CacheStorage interface {
 	Client() any
+	Ping(ctx context.Context) error
 	Close() error
 }
 
 type Container struct {
 	sqlStorage   SQLStorage
 	cacheStorage CacheStorage
+	sqlType      string
+	cacheType    string
 	logger       *logger.Logger
 }
 
@@ -51,6 +56,8 @@ func NewContainer(cfg *config.Config, logger *logger.Logger) (*Container, error)
 	storage := &Container{
 		sqlStorage:   db,
 		cacheStorage: cache,
+		sqlType:      cfg.App.DBType,
+		cacheType:    cfg.App.CacheType,
 		logger:       logger,
 	}
 	return storage, nil
@@ -64,6 +71,15 @@ func (c *Container) SQL() any {
 	return c.sqlStorage.DB()
 }
 
+// HealthChecks returns a check per dependency, keyed by its name.
+func (c *Container) HealthChecks() map[string]func(ctx context.Context) error {
+	return map[string]func(ctx context.Context) error{
+		c.sqlType:    c.sqlStorage.Ping,
+		"migrations": c.sqlStorage.CheckMigrations,
+		c.cacheType:  c.cacheStorage.Ping,
+	}
+}
+
 func (c *Container) Close(ctx context.Context) error {
 	c.logger.Debug("Shutting down storage")
 	go c.sqlStorage.Close()
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"github.com/Roflan4eg/auth-serivce/config"
 	"github.com/Roflan4eg/auth-serivce/internal/lib/logger"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -66,3 +68,24 @@ func RunMigrations(cfg *config.PostgresConfig, logger *logger.Logger) error {
 		logger.Bool("dirty", dirty))
 	return nil
 }
+
+// latestMigration returns the version of the newest embedded migration.
+func latestMigration() (uint, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+	var latest uint
+	for _, entry := range entries {
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		if !ok || !strings.HasSuffix(entry.Name(), ".up.sql") {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		latest = max(latest, uint(version))
+	}
+	return latest, nil
+}
@@ -0,0 +1,48 @@
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(64) UNIQUE NOT NULL,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(128) UNIQUE NOT NULL
+);
+
+CREATE TABLE role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_user_id ON user_roles(user_id);
+COMMENT ON TABLE user_roles IS 'Roles granted to users, permissions are resolved through role_permissions';
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access to user and role management'),
+    ('service', 'Trusted backend services');
+
+INSERT INTO permissions (name) VALUES
+    ('users:create'),
+    ('users:read'),
+    ('users:update'),
+    ('users:deactivate'),
+    ('users:validate_password'),
+    ('sessions:read'),
+    ('sessions:revoke'),
+    ('roles:manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name IN ('users:read', 'users:validate_password', 'sessions:read')
+WHERE r.name = 'service';
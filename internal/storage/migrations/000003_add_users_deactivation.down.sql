@@ -0,0 +1,5 @@
+ALTER TABLE users ALTER COLUMN is_active DROP NOT NULL;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deactivation_reason,
+    DROP COLUMN IF EXISTS deactivated_at;
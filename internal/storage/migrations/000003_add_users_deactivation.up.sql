@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN deactivated_at TIMESTAMPTZ,
+    ADD COLUMN deactivation_reason TEXT NOT NULL DEFAULT '';
+
+UPDATE users SET is_active = TRUE WHERE is_active IS NULL;
+ALTER TABLE users ALTER COLUMN is_active SET NOT NULL;
@@ -0,0 +1,4 @@
+-- an empty hash matches no password, the users stay passwordless
+UPDATE users SET password = ''::BYTEA WHERE password IS NULL;
+ALTER TABLE users ALTER COLUMN password SET NOT NULL;
+COMMENT ON COLUMN users.password IS NULL;
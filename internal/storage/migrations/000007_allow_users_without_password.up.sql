@@ -0,0 +1,3 @@
+ALTER TABLE users ALTER COLUMN password DROP NOT NULL;
+
+COMMENT ON COLUMN users.password IS 'Password hash, NULL for users signing in without a password';
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/tracing"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/lib/pq"
 )
@@ -19,6 +22,7 @@ func NewPostgresStorage(cfg *config.PostgresConfig) (*PostgresStorage, error) {
 	}
 	poolConfig.MaxConns = int32(cfg.MaxConns)
 	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.ConnConfig.Tracer = tracing.NewPgxTracer()
 
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
 	defer cancel()
@@ -43,3 +47,32 @@ func (s *PostgresStorage) Close() error {
 func (s *PostgresStorage) DB() any {
 	return s.pool
 }
+
+func (s *PostgresStorage) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+// CheckMigrations fails unless the schema is at the latest embedded
+// migration and not left dirty by a failed one.
+func (s *PostgresStorage) CheckMigrations(ctx context.Context) error {
+	latest, err := latestMigration()
+	if err != nil {
+		return err
+	}
+	var (
+		version uint
+		dirty   bool
+	)
+	err = s.pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return errors.New("no migrations applied")
+	case err != nil:
+		return fmt.Errorf("get migration version: %w", err)
+	case dirty:
+		return fmt.Errorf("migration %d is dirty", version)
+	case version < latest:
+		return fmt.Errorf("migration %d applied, %d expected", version, latest)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/Roflan4eg/auth-serivce/config"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -17,6 +18,7 @@ func NewRedisClient(cfg *config.RedisConfig) (*RedisClient, error) {
 		Password: cfg.Password,
 		DB:       cfg.DB,
 	})
+	client.AddHook(tracing.NewRedisHook())
 	_, err := client.Ping(context.Background()).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to ping redis: %w", err)
@@ -36,3 +38,7 @@ func (s *RedisClient) Close() error {
 func (s *RedisClient) Client() any {
 	return s.client
 }
+
+func (s *RedisClient) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
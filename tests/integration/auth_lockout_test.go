@@ -0,0 +1,63 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestLogin_FailuresAreDelayed(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+	email := gofakeit.Email()
+	_, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+
+	for i := 0; i < st.Cfg.Security.LoginDelayAfter; i++ {
+		_, err = st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: suite.RandomPass()})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	// even the right password waits for the delay
+	_, err = st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: pass})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	var retryInfo *errdetails.RetryInfo
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryInfo = info
+		}
+	}
+	require.NotNil(t, retryInfo)
+	assert.Positive(t, retryInfo.GetRetryDelay().AsDuration())
+	assert.LessOrEqual(t, retryInfo.GetRetryDelay().AsDuration(), st.Cfg.Security.LoginDelayBase)
+}
+
+func TestLogin_UnknownEmailIsDelayed(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+
+	for i := 0; i < st.Cfg.Security.LoginDelayAfter; i++ {
+		_, err := st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: suite.RandomPass()})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+	_, err := st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: suite.RandomPass()})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestUnlockUser_RequiresPermission(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: gofakeit.Email(), Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	claims, err := suite.JWTParse(reg.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+
+	_, err = st.UserClient.UnlockUser(suite.WithToken(ctx, reg.GetAccessToken()), &auth.UnlockUserRequest{UserId: claims["uid"].(string)})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"testing"
 	"time"
 )
@@ -55,6 +57,9 @@ func TestRegisterLogin_Login_Success(t *testing.T) {
 	logAccClaims, err := suite.JWTParse(accessToken, secret)
 	require.NoError(t, err)
 	assert.Equal(t, regAccClaims["uid"].(string), logAccClaims["uid"].(string))
+	assert.Equal(t, st.Cfg.JWTConfig.Issuer, logAccClaims["iss"].(string))
+	assert.Equal(t, logAccClaims["uid"].(string), logAccClaims["sub"].(string))
+	assert.NotEqual(t, logAccClaims["jti"].(string), logRefClaims["jti"].(string))
 	// check expiration
 	assert.InDelta(t, loginTime.Add(st.Cfg.JWTConfig.RefreshTokenTTL).Unix(), logRefClaims["exp"].(float64), float64(deltaSec))
 	assert.InDelta(t, loginTime.Add(st.Cfg.JWTConfig.AccessTokenTTL).Unix(), logAccClaims["exp"].(float64), float64(deltaSec))
@@ -106,8 +111,8 @@ func TestRegisterLogin_UserWrongPassword(t *testing.T) {
 		Email:    email,
 		Password: suite.RandomPass(),
 	})
-	require.Error(t, err)
-	assert.ErrorContains(t, err, "invalid password")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorContains(t, err, "invalid credentials")
 
 }
 
@@ -215,7 +220,7 @@ func TestLogin_FailCases(t *testing.T) {
 			name:        "Login with non-existing user",
 			email:       gofakeit.Email(),
 			password:    suite.RandomPass(),
-			expectedErr: "user not found",
+			expectedErr: "invalid credentials",
 		},
 	}
 	for _, c := range cases {
@@ -230,3 +235,42 @@ func TestLogin_FailCases(t *testing.T) {
 	}
 
 }
+
+func TestRegister_EmailNotVerified(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: gofakeit.Email(), Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	claims, err := suite.JWTParse(reg.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+
+	user, err := st.UserClient.GetUserById(suite.WithToken(ctx, reg.GetAccessToken()), &auth.GetUserRequest{UserId: claims["uid"].(string)})
+	require.NoError(t, err)
+	assert.False(t, user.GetEmailVerified())
+
+	_, err = st.AuthClient.VerifyEmail(ctx, &auth.VerifyEmailRequest{Token: reg.GetRefreshToken()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPasswordReset_NoEnumeration(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+	email := gofakeit.Email()
+
+	_, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RequestPasswordReset(ctx, &auth.RequestPasswordResetRequest{Email: email})
+	require.NoError(t, err)
+	_, err = st.AuthClient.RequestPasswordReset(ctx, &auth.RequestPasswordResetRequest{Email: gofakeit.Email()})
+	require.NoError(t, err)
+
+	newPass := suite.RandomPass()
+	_, err = st.AuthClient.ResetPassword(ctx, &auth.ResetPasswordRequest{
+		Token:              "not-a-reset-token",
+		NewPassword:        newPass,
+		NewPasswordConfirm: newPass,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/totp"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestMFA_TOTPLogin(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	authCtx := suite.WithToken(ctx, reg.GetAccessToken())
+
+	enrollment, err := st.AuthClient.EnrollTOTP(authCtx, &auth.EnrollTOTPRequest{})
+	if status.Code(err) == codes.FailedPrecondition {
+		t.Skip("MFA_ENCRYPTION_KEY is not configured")
+	}
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.GetOtpauthUrl(), "otpauth://totp/")
+
+	code, err := totp.Code(enrollment.GetSecret(), totp.Step(time.Now()))
+	require.NoError(t, err)
+	confirmed, err := st.AuthClient.ConfirmTOTP(authCtx, &auth.ConfirmTOTPRequest{Code: code})
+	require.NoError(t, err)
+	require.NotEmpty(t, confirmed.GetRecoveryCodes())
+
+	login, err := st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: pass})
+	require.NoError(t, err)
+	assert.True(t, login.GetMfaRequired())
+	assert.Empty(t, login.GetAccessToken())
+	require.NotEmpty(t, login.GetMfaToken())
+
+	// the code used for confirmation can't be replayed
+	_, err = st.AuthClient.VerifyMFA(ctx, &auth.VerifyMFARequest{MfaToken: login.GetMfaToken(), Code: code})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	recovery := confirmed.GetRecoveryCodes()[0]
+	ses, err := st.AuthClient.VerifyMFA(ctx, &auth.VerifyMFARequest{MfaToken: login.GetMfaToken(), Code: recovery})
+	require.NoError(t, err)
+	assert.NotEmpty(t, ses.GetAccessToken())
+
+	// both the challenge and the recovery code are single-use
+	_, err = st.AuthClient.VerifyMFA(ctx, &auth.VerifyMFARequest{MfaToken: login.GetMfaToken(), Code: confirmed.GetRecoveryCodes()[1]})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	login, err = st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: pass})
+	require.NoError(t, err)
+	_, err = st.AuthClient.VerifyMFA(ctx, &auth.VerifyMFARequest{MfaToken: login.GetMfaToken(), Code: recovery})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = st.AuthClient.DisableTOTP(suite.WithToken(ctx, ses.GetAccessToken()), &auth.DisableTOTPRequest{Code: confirmed.GetRecoveryCodes()[2]})
+	require.NoError(t, err)
+	login, err = st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: pass})
+	require.NoError(t, err)
+	assert.False(t, login.GetMfaRequired())
+	assert.NotEmpty(t, login.GetAccessToken())
+}
+
+func TestMFA_DisableTOTPThrottled(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: gofakeit.Email(), Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	authCtx := suite.WithToken(ctx, reg.GetAccessToken())
+
+	enrollment, err := st.AuthClient.EnrollTOTP(authCtx, &auth.EnrollTOTPRequest{})
+	if status.Code(err) == codes.FailedPrecondition {
+		t.Skip("MFA_ENCRYPTION_KEY is not configured")
+	}
+	require.NoError(t, err)
+	code, err := totp.Code(enrollment.GetSecret(), totp.Step(time.Now()))
+	require.NoError(t, err)
+	confirmed, err := st.AuthClient.ConfirmTOTP(authCtx, &auth.ConfirmTOTPRequest{Code: code})
+	require.NoError(t, err)
+
+	for i := 0; i < st.Cfg.MFA.MaxAttempts; i++ {
+		_, err = st.AuthClient.DisableTOTP(authCtx, &auth.DisableTOTPRequest{Code: "000000"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	// a session alone can't guess its way past the limit
+	_, err = st.AuthClient.DisableTOTP(authCtx, &auth.DisableTOTPRequest{Code: confirmed.GetRecoveryCodes()[0]})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,102 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestPasskey_RegisterAndLogin(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: gofakeit.Email(), Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	authCtx := suite.WithToken(ctx, reg.GetAccessToken())
+	authenticator := suite.NewAuthenticator(st.Cfg.WebAuthn.RPOrigins[0])
+
+	ceremony, err := st.AuthClient.BeginPasskeyRegistration(authCtx, &auth.BeginPasskeyRegistrationRequest{})
+	require.NoError(t, err)
+	credential, err := authenticator.Create(ceremony.GetOptions())
+	require.NoError(t, err)
+	passkey, err := st.AuthClient.FinishPasskeyRegistration(authCtx, &auth.FinishPasskeyRegistrationRequest{
+		CeremonyId: ceremony.GetCeremonyId(),
+		Credential: credential,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, passkey.GetId())
+	assert.Equal(t, []string{"internal"}, passkey.GetTransports())
+
+	// a ceremony can be finished only once
+	_, err = st.AuthClient.FinishPasskeyRegistration(authCtx, &auth.FinishPasskeyRegistrationRequest{
+		CeremonyId: ceremony.GetCeremonyId(),
+		Credential: credential,
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	login, err := st.AuthClient.BeginPasskeyLogin(ctx, &auth.BeginPasskeyLoginRequest{})
+	require.NoError(t, err)
+	assertion, err := authenticator.Get(login.GetOptions())
+	require.NoError(t, err)
+	ses, err := st.AuthClient.FinishPasskeyLogin(ctx, &auth.FinishPasskeyLoginRequest{
+		CeremonyId: login.GetCeremonyId(),
+		Credential: assertion,
+		DeviceName: "passkey test",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, ses.GetAccessToken())
+
+	valid, err := st.AuthClient.ValidateToken(ctx, &auth.ValidateTokenRequest{Token: ses.GetAccessToken()})
+	require.NoError(t, err)
+	assert.True(t, valid.GetValid())
+	validReg, err := st.AuthClient.ValidateToken(ctx, &auth.ValidateTokenRequest{Token: reg.GetAccessToken()})
+	require.NoError(t, err)
+	assert.Equal(t, validReg.GetUserId(), valid.GetUserId())
+}
+
+func TestPasskey_LoginRejected(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: gofakeit.Email(), Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	authCtx := suite.WithToken(ctx, reg.GetAccessToken())
+	authenticator := suite.NewAuthenticator(st.Cfg.WebAuthn.RPOrigins[0])
+
+	ceremony, err := st.AuthClient.BeginPasskeyRegistration(authCtx, &auth.BeginPasskeyRegistrationRequest{})
+	require.NoError(t, err)
+	credential, err := authenticator.Create(ceremony.GetOptions())
+	require.NoError(t, err)
+	_, err = st.AuthClient.FinishPasskeyRegistration(authCtx, &auth.FinishPasskeyRegistrationRequest{
+		CeremonyId: ceremony.GetCeremonyId(),
+		Credential: credential,
+	})
+	require.NoError(t, err)
+	clone := authenticator.Clone()
+
+	loginWith := func(a *suite.Authenticator) error {
+		login, err := st.AuthClient.BeginPasskeyLogin(ctx, &auth.BeginPasskeyLoginRequest{})
+		require.NoError(t, err)
+		assertion, err := a.Get(login.GetOptions())
+		require.NoError(t, err)
+		_, err = st.AuthClient.FinishPasskeyLogin(ctx, &auth.FinishPasskeyLoginRequest{
+			CeremonyId: login.GetCeremonyId(),
+			Credential: assertion,
+		})
+		return err
+	}
+	require.NoError(t, loginWith(authenticator))
+
+	t.Run("cloned key", func(t *testing.T) {
+		// the clone signs with a counter the server has already seen
+		assert.Equal(t, codes.Unauthenticated, status.Code(loginWith(clone)))
+	})
+	t.Run("foreign origin", func(t *testing.T) {
+		phishing := authenticator.Clone()
+		phishing.Origin = "https://phishing.example"
+		assert.Equal(t, codes.Unauthenticated, status.Code(loginWith(phishing)))
+	})
+}
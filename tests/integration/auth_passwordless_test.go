@@ -0,0 +1,67 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestPasswordless_StartDoesNotRevealAccounts(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+	email := gofakeit.Email()
+	_, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+
+	for _, e := range []string{email, gofakeit.Email()} {
+		_, err = st.AuthClient.StartPasswordlessLogin(ctx, &auth.StartPasswordlessLoginRequest{Email: e})
+		assert.NoError(t, err)
+		_, err = st.AuthClient.StartPasswordlessLogin(ctx, &auth.StartPasswordlessLoginRequest{
+			Email: e,
+			Mode:  auth.PasswordlessMode_PASSWORDLESS_MODE_CODE,
+		})
+		assert.NoError(t, err)
+	}
+}
+
+func TestPasswordless_WrongCodeIsLimited(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	_, err := st.AuthClient.StartPasswordlessLogin(ctx, &auth.StartPasswordlessLoginRequest{
+		Email: email,
+		Mode:  auth.PasswordlessMode_PASSWORDLESS_MODE_CODE,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < st.Cfg.Security.PasswordlessMaxAttempts; i++ {
+		_, err = st.AuthClient.CompletePasswordlessLogin(ctx, &auth.CompletePasswordlessLoginRequest{Email: email, Code: "000000"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+	_, err = st.AuthClient.CompletePasswordlessLogin(ctx, &auth.CompletePasswordlessLoginRequest{Email: email, Code: "000000"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestPasswordless_CompleteFail(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	tests := []struct {
+		name string
+		req  *auth.CompletePasswordlessLoginRequest
+	}{
+		{name: "unknown token", req: &auth.CompletePasswordlessLoginRequest{Token: "not-a-token"}},
+		{name: "nothing", req: &auth.CompletePasswordlessLoginRequest{}},
+		{name: "code without email", req: &auth.CompletePasswordlessLoginRequest{Code: "123456"}},
+		{name: "short code", req: &auth.CompletePasswordlessLoginRequest{Email: gofakeit.Email(), Code: "123"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := st.AuthClient.CompletePasswordlessLogin(ctx, tt.req)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/internal/lib/jwt"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestRefreshToken_Rotation(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:           gofakeit.Email(),
+		Password:        pass,
+		PasswordConfirm: pass,
+	})
+	require.NoError(t, err)
+
+	first, err := st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: reg.GetRefreshToken()})
+	require.NoError(t, err)
+	assert.NotEqual(t, reg.GetRefreshToken(), first.GetRefreshToken())
+
+	// a concurrent refresh with the same token inside the grace window gets the rotated session
+	again, err := st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: reg.GetRefreshToken()})
+	require.NoError(t, err)
+	assert.Equal(t, first.GetRefreshToken(), again.GetRefreshToken())
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:           gofakeit.Email(),
+		Password:        pass,
+		PasswordConfirm: pass,
+	})
+	require.NoError(t, err)
+
+	first, err := st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: reg.GetRefreshToken()})
+	require.NoError(t, err)
+	second, err := st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: first.GetRefreshToken()})
+	require.NoError(t, err)
+
+	// the original token is two rotations old now
+	_, err = st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: reg.GetRefreshToken()})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.ErrorContains(t, err, "refresh token reuse detected")
+
+	// and the legitimate holder is logged out as well
+	_, err = st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: second.GetRefreshToken()})
+	require.Error(t, err)
+}
+
+func TestRefreshToken_RejectsOtherTokens(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:           gofakeit.Email(),
+		Password:        pass,
+		PasswordConfirm: pass,
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: reg.GetAccessToken()})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// expiry is truncated to whole seconds, a second later it has passed
+	conf := *st.Cfg.JWTConfig
+	conf.AccessTokenTTL = time.Millisecond
+	conf.RefreshTokenTTL = 2 * time.Millisecond
+	manager, err := jwt.NewManager(&conf)
+	require.NoError(t, err)
+	expired, err := manager.GenerateRefreshToken("user-id", "session-id")
+	require.NoError(t, err)
+	time.Sleep(time.Second)
+
+	_, err = st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: expired})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
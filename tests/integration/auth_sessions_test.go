@@ -0,0 +1,105 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestSessions_ListAndRevoke(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:           email,
+		Password:        pass,
+		PasswordConfirm: pass,
+	})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: pass, DeviceName: "Pixel 8"})
+		require.NoError(t, err)
+	}
+
+	claims, err := suite.JWTParse(reg.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+	userID := claims["uid"].(string)
+	currentSessionID := claims["sid"].(string)
+	ctx = suite.WithToken(ctx, reg.GetAccessToken())
+
+	page, err := st.AuthClient.ListSessions(ctx, &auth.ListSessionsRequest{UserId: userID, PageSize: 2})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, page.GetTotal())
+	assert.Len(t, page.GetSessions(), 2)
+	require.NotEmpty(t, page.GetNextPageToken())
+	for _, ses := range page.GetSessions() {
+		assert.Equal(t, "Pixel 8", ses.GetDeviceName())
+		assert.NotEmpty(t, ses.GetIpAddress())
+		assert.NotEmpty(t, ses.GetUserAgent())
+	}
+
+	next, err := st.AuthClient.ListSessions(ctx, &auth.ListSessionsRequest{
+		UserId:    userID,
+		PageSize:  2,
+		PageToken: page.GetNextPageToken(),
+	})
+	require.NoError(t, err)
+	assert.Len(t, next.GetSessions(), 1)
+	assert.Empty(t, next.GetNextPageToken())
+
+	ses, err := st.AuthClient.GetSession(ctx, &auth.GetSessionRequest{SessionId: currentSessionID})
+	require.NoError(t, err)
+	assert.Equal(t, userID, ses.GetUserId())
+
+	_, err = st.AuthClient.RevokeAllSessions(ctx, &auth.RevokeAllSessionsRequest{
+		UserId:          userID,
+		ExceptSessionId: currentSessionID,
+	})
+	require.NoError(t, err)
+
+	page, err = st.AuthClient.ListSessions(ctx, &auth.ListSessionsRequest{UserId: userID})
+	require.NoError(t, err)
+	require.Len(t, page.GetSessions(), 1)
+	assert.Equal(t, currentSessionID, page.GetSessions()[0].GetId())
+
+	_, err = st.AuthClient.RevokeSession(ctx, &auth.RevokeSessionRequest{SessionId: currentSessionID})
+	require.NoError(t, err)
+	// the revoked session can no longer authenticate
+	_, err = st.AuthClient.GetSession(ctx, &auth.GetSessionRequest{SessionId: currentSessionID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestSessions_RequireAuthentication(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.AuthClient.ListSessions(ctx, &auth.ListSessionsRequest{UserId: gofakeit.UUID()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = st.AuthClient.ListSessions(suite.WithToken(ctx, "not-a-token"), &auth.ListSessionsRequest{UserId: gofakeit.UUID()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestLogout_RevokesCurrentSession(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{
+		Email:           gofakeit.Email(),
+		Password:        pass,
+		PasswordConfirm: pass,
+	})
+	require.NoError(t, err)
+
+	authCtx := suite.WithToken(ctx, reg.GetAccessToken())
+	_, err = st.AuthClient.Logout(authCtx, &auth.LogoutRequest{})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Logout(authCtx, &auth.LogoutRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,29 @@
+package integration
+
+import (
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestHealth_ReportsDependencies(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	for _, service := range []string{"", "auth.AuthService", "user.UserService", "postgres", "redis", "migrations"} {
+		resp, err := st.Health.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err, service)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus(), service)
+	}
+}
+
+func TestHealth_UnknownService(t *testing.T) {
+	ctx, st := suite.New(t)
+
+	_, err := st.Health.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
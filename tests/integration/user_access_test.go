@@ -0,0 +1,41 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestUserAccess_SelfServiceOnly(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	email := gofakeit.Email()
+	me, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	otherEmail := gofakeit.Email()
+	_, err = st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: otherEmail, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+
+	claims, err := suite.JWTParse(me.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+	authCtx := suite.WithToken(ctx, me.GetAccessToken())
+
+	user, err := st.UserClient.GetUserByEmail(authCtx, &auth.GetUserByEmailRequest{Email: email})
+	require.NoError(t, err)
+	assert.Equal(t, claims["uid"].(string), user.GetId())
+
+	_, err = st.UserClient.GetUserByEmail(authCtx, &auth.GetUserByEmailRequest{Email: otherEmail})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.UserClient.AssignRole(authCtx, &auth.AssignRoleRequest{UserId: user.GetId(), Role: "admin"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = st.AuthClient.ListSessions(authCtx, &auth.ListSessionsRequest{UserId: gofakeit.UUID()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
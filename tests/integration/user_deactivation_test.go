@@ -0,0 +1,43 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestUser_DeactivateSelf(t *testing.T) {
+	ctx, st := suite.New(t)
+	email := gofakeit.Email()
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	other, err := st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: pass})
+	require.NoError(t, err)
+
+	claims, err := suite.JWTParse(reg.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+	userID := claims["uid"].(string)
+
+	_, err = st.UserClient.DeactivateUser(suite.WithToken(ctx, reg.GetAccessToken()), &auth.DeactivateUserRequest{
+		UserId: userID,
+		Reason: "closed by owner",
+	})
+	require.NoError(t, err)
+
+	_, err = st.AuthClient.Login(ctx, &auth.LoginRequest{Email: email, Password: pass})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// every session was revoked
+	_, err = st.AuthClient.RefreshToken(ctx, &auth.RefreshTokenRequest{RefreshToken: other.GetRefreshToken()})
+	assert.Error(t, err)
+	valid, err := st.AuthClient.ValidateToken(ctx, &auth.ValidateTokenRequest{Token: other.GetAccessToken()})
+	require.NoError(t, err)
+	assert.False(t, valid.GetValid())
+}
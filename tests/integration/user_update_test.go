@@ -0,0 +1,44 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestUser_UpdateEmailRequest(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+	email := gofakeit.Email()
+	takenEmail := gofakeit.Email()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: email, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	_, err = st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: takenEmail, Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	claims, err := suite.JWTParse(reg.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+	userID := claims["uid"].(string)
+	authCtx := suite.WithToken(ctx, reg.GetAccessToken())
+
+	resp, err := st.UserClient.UpdateUser(authCtx, &auth.UpdateUserRequest{UserId: userID, Email: email})
+	require.NoError(t, err)
+	assert.False(t, resp.GetEmailChangePending())
+
+	_, err = st.UserClient.UpdateUser(authCtx, &auth.UpdateUserRequest{UserId: userID, Email: takenEmail})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	resp, err = st.UserClient.UpdateUser(authCtx, &auth.UpdateUserRequest{UserId: userID, Email: gofakeit.Email()})
+	require.NoError(t, err)
+	assert.True(t, resp.GetEmailChangePending())
+	// the email only changes once confirmed
+	assert.Equal(t, email, resp.GetUser().GetEmail())
+
+	_, err = st.UserClient.ConfirmEmailChange(ctx, &auth.ConfirmEmailChangeRequest{Token: reg.GetAccessToken()})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
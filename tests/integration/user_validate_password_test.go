@@ -0,0 +1,70 @@
+package integration
+
+import (
+	auth "github.com/Roflan4eg/auth-serivce/internal/interfaces/grpc/pb"
+	"github.com/Roflan4eg/auth-serivce/tests/suite"
+	"github.com/brianvoe/gofakeit/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestUser_ValidatePasswordThrottled(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: gofakeit.Email(), Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	claims, err := suite.JWTParse(reg.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+	userID := claims["uid"].(string)
+	ctx = suite.WithToken(ctx, reg.GetAccessToken())
+
+	resp, err := st.UserClient.ValidatePassword(ctx, &auth.ValidatePasswordRequest{UserId: userID, Password: pass})
+	require.NoError(t, err)
+	assert.True(t, resp.GetIsValid())
+
+	for i := 0; i < st.Cfg.Security.PasswordCheckMaxAttempts; i++ {
+		resp, err = st.UserClient.ValidatePassword(ctx, &auth.ValidatePasswordRequest{UserId: userID, Password: "wrong"})
+		require.NoError(t, err)
+		assert.False(t, resp.GetIsValid())
+	}
+
+	// the correct password is refused as well until the window expires
+	_, err = st.UserClient.ValidatePassword(ctx, &auth.ValidatePasswordRequest{UserId: userID, Password: pass})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestUser_ValidatePasswordConcurrentChecksAreLimited(t *testing.T) {
+	ctx, st := suite.New(t)
+	pass := suite.RandomPass()
+
+	reg, err := st.AuthClient.Register(ctx, &auth.RegisterRequest{Email: gofakeit.Email(), Password: pass, PasswordConfirm: pass})
+	require.NoError(t, err)
+	claims, err := suite.JWTParse(reg.GetAccessToken(), st.Cfg.JWTConfig.Secret)
+	require.NoError(t, err)
+	userID := claims["uid"].(string)
+	ctx = suite.WithToken(ctx, reg.GetAccessToken())
+
+	calls := st.Cfg.Security.PasswordCheckMaxAttempts + 5
+	var (
+		wg      sync.WaitGroup
+		checked atomic.Int32
+	)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := st.UserClient.ValidatePassword(ctx, &auth.ValidatePasswordRequest{UserId: userID, Password: "wrong"})
+			if err == nil {
+				checked.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(st.Cfg.Security.PasswordCheckMaxAttempts), checked.Load())
+}
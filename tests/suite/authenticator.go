@@ -0,0 +1,188 @@
+package suite
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// Authenticator is a software passkey authenticator. It answers the options
+// returned by the Begin* RPCs the way navigator.credentials would, with
+// ES256 keys and "none" attestation.
+type Authenticator struct {
+	Origin      string
+	credentials []*softCredential
+}
+
+type softCredential struct {
+	id         []byte
+	rpID       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+}
+
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin}
+}
+
+// Create answers navigator.credentials.create() options with a new passkey.
+func (a *Authenticator) Create(options string) (string, error) {
+	var creation protocol.CredentialCreation
+	if err := json.Unmarshal([]byte(options), &creation); err != nil {
+		return "", err
+	}
+	userID, ok := creation.Response.User.ID.(string)
+	if !ok {
+		return "", errors.New("unexpected user id")
+	}
+	userHandle, err := base64.RawURLEncoding.DecodeString(userID)
+	if err != nil {
+		return "", err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	cred := &softCredential{
+		id:         make([]byte, 32),
+		rpID:       creation.Response.RelyingParty.ID,
+		userHandle: userHandle,
+		key:        key,
+	}
+	if _, err = rand.Read(cred.id); err != nil {
+		return "", err
+	}
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		return "", err
+	}
+	authData := cred.authData(flagUserPresent | flagUserVerified | flagAttestedData)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(cred.id)))
+	authData = append(authData, cred.id...)
+	authData = append(authData, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+	if err != nil {
+		return "", err
+	}
+	clientData, err := a.clientData("webauthn.create", creation.Response.Challenge)
+	if err != nil {
+		return "", err
+	}
+	a.credentials = append(a.credentials, cred)
+
+	return encodeCredential(cred.id, map[string]any{
+		"clientDataJSON":    b64(clientData),
+		"attestationObject": b64(attestation),
+		"transports":        []string{"internal"},
+	})
+}
+
+// Get answers navigator.credentials.get() options with the first passkey
+// matching the relying party, as if the user picked it.
+func (a *Authenticator) Get(options string) (string, error) {
+	var assertion protocol.CredentialAssertion
+	if err := json.Unmarshal([]byte(options), &assertion); err != nil {
+		return "", err
+	}
+	var cred *softCredential
+	for _, c := range a.credentials {
+		if c.rpID == assertion.Response.RelyingPartyID {
+			cred = c
+			break
+		}
+	}
+	if cred == nil {
+		return "", errors.New("no passkey for the relying party")
+	}
+
+	cred.signCount++
+	authData := cred.authData(flagUserPresent | flagUserVerified)
+	clientData, err := a.clientData("webauthn.get", assertion.Response.Challenge)
+	if err != nil {
+		return "", err
+	}
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return encodeCredential(cred.id, map[string]any{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(cred.userHandle),
+	})
+}
+
+// Clone returns an authenticator holding copies of the passkeys, including
+// their signature counters.
+func (a *Authenticator) Clone() *Authenticator {
+	clone := &Authenticator{Origin: a.Origin}
+	for _, c := range a.credentials {
+		copied := *c
+		clone.credentials = append(clone.credentials, &copied)
+	}
+	return clone
+}
+
+func (c *softCredential) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, c.signCount)
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge protocol.URLEncodedBase64) ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   b64(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+}
+
+func encodeCredential(id []byte, response map[string]any) (string, error) {
+	raw, err := json.Marshal(map[string]any{
+		"id":                      b64(id),
+		"rawId":                   b64(id),
+		"type":                    "public-key",
+		"authenticatorAttachment": "platform",
+		"response":                response,
+	})
+	return string(raw), err
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/joho/godotenv"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"math/rand"
 	"testing"
 )
@@ -19,6 +21,8 @@ type Suite struct {
 	*testing.T
 	Cfg        *config.Config
 	AuthClient auth.AuthServiceClient
+	UserClient auth.UserServiceClient
+	Health     healthpb.HealthClient
 }
 
 func New(t *testing.T) (context.Context, *Suite) {
@@ -39,8 +43,18 @@ func New(t *testing.T) (context.Context, *Suite) {
 	if err != nil {
 		t.Fatalf("Failed to connect to gRPC server: %v", err)
 	}
-	return ctx, &Suite{Cfg: cfg, AuthClient: auth.NewAuthServiceClient(cc)}
+	return ctx, &Suite{
+		Cfg:        cfg,
+		AuthClient: auth.NewAuthServiceClient(cc),
+		UserClient: auth.NewUserServiceClient(cc),
+		Health:     healthpb.NewHealthClient(cc),
+	}
+
+}
 
+// WithToken attaches the access token as bearer credentials to outgoing calls.
+func WithToken(ctx context.Context, accessToken string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken)
 }
 
 func RandomPass() string {